@@ -0,0 +1,147 @@
+package chatclient
+
+import (
+	"context"
+	"encoding/json"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// Tool is a higher-level alternative to ToolRegistry.Register/RegisterFatal
+// for callers who'd rather implement an interface than pass a closure.
+type Tool interface {
+	Name() string
+	Description() string
+	JSONSchema() any
+	Invoke(ctx context.Context, argsJSON json.RawMessage) (string, error)
+}
+
+// Agent wraps a Conversation with a registered tool set and an optional
+// system prompt, driving the same tool-calling loop as
+// Conversation.PromptWithTools/PromptStreamWithTools through a Tool-based API.
+type Agent struct {
+	conv     *Conversation
+	registry *ToolRegistry
+}
+
+// NewAgent starts a new Agent on its own Conversation against client,
+// instructed with systemPrompt (a no-op if systemPrompt is empty).
+func NewAgent(client *Client, systemPrompt string) (*Agent, error) {
+	conv := client.NewConversation()
+
+	if systemPrompt != "" {
+		if err := conv.Instruct(systemPrompt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Agent{conv: conv, registry: NewToolRegistry()}, nil
+}
+
+// RegisterTool makes t available for the model to call on subsequent prompts.
+func (a *Agent) RegisterTool(t Tool) {
+	a.registry.Register(t.Name(), t.Description(), t.JSONSchema(), func(ctx context.Context, args json.RawMessage) (string, error) {
+		return t.Invoke(ctx, args)
+	})
+}
+
+// Prompt sends prompt to the agent, dispatching any tool calls the model
+// makes along the way. See Conversation.PromptWithTools for the full
+// tool-calling loop semantics.
+func (a *Agent) Prompt(ctx context.Context, prompt string) (string, error) {
+	return a.conv.PromptWithTools(ctx, prompt, a.registry)
+}
+
+// AgentEventType identifies the kind of event carried by an AgentEvent.
+type AgentEventType int
+
+const (
+	// AgentEventContent carries the model's final plain-text answer.
+	AgentEventContent AgentEventType = iota
+	// AgentEventToolCall reports that the model is invoking a tool, so
+	// callers can render progress such as "assistant is running tool X".
+	AgentEventToolCall
+	// AgentEventError carries a terminal error; no further events follow.
+	AgentEventError
+)
+
+// AgentAction describes one tool invocation the model requested.
+type AgentAction struct {
+	Tool      string
+	Arguments string
+}
+
+// AgentEvent is one event on the channel returned by Agent.PromptStream.
+type AgentEvent struct {
+	Type    AgentEventType
+	Content string
+	Action  AgentAction
+	Err     error
+}
+
+// PromptStream behaves like Prompt, except intermediate tool calls are
+// reported as AgentEventToolCall events before being dispatched, and the
+// final answer arrives as a single AgentEventContent event (tool-calling
+// rounds are not themselves streamed - see Conversation.PromptStreamWithTools
+// for why).
+func (a *Agent) PromptStream(ctx context.Context, prompt string) <-chan AgentEvent {
+
+	ch := make(chan AgentEvent)
+
+	go func() {
+		defer close(ch)
+
+		conv := a.conv
+		conv.mu.Lock()
+		defer conv.mu.Unlock()
+
+		c := conv.client
+
+		if c.shouldModerateInput() {
+			err := c.moderateInput(ctx, prompt)
+			if err == ErrModeration {
+				ch <- AgentEvent{Type: AgentEventError, Err: ErrModerationUserInput}
+				return
+			}
+			if err != nil {
+				ch <- AgentEvent{Type: AgentEventError, Err: err}
+				return
+			}
+		}
+
+		userMsg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleUser, Content: prompt}
+		conv.history = append(conv.history, userMsg)
+		conv.persist(userMsg)
+
+		onToolCall := func(call provider.ToolCall) {
+			ch <- AgentEvent{
+				Type:   AgentEventToolCall,
+				Action: AgentAction{Tool: call.Name, Arguments: call.Arguments},
+			}
+		}
+
+		response, err := conv.runToolLoop(ctx, a.registry, onToolCall)
+		if err != nil {
+			ch <- AgentEvent{Type: AgentEventError, Err: err}
+			return
+		}
+
+		if c.shouldModerateOutput() {
+			err := c.moderateInput(ctx, response)
+			if err == ErrModeration {
+				ch <- AgentEvent{Type: AgentEventError, Err: ErrModerationModelOutput}
+				return
+			}
+			if err != nil {
+				ch <- AgentEvent{Type: AgentEventError, Err: err}
+				return
+			}
+		}
+
+		ch <- AgentEvent{Type: AgentEventContent, Content: response}
+	}()
+
+	return ch
+}
@@ -3,49 +3,62 @@ package chatclient
 import (
 	"context"
 	"fmt"
-	"log"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	ai "github.com/sashabaranov/go-openai"
+	"github.com/google/uuid"
+
+	"github.com/m-ariany/gpt-chat-client/finetune"
+	"github.com/m-ariany/gpt-chat-client/provider"
+	"github.com/m-ariany/gpt-chat-client/providers/anthropic"
+	"github.com/m-ariany/gpt-chat-client/providers/azureopenai"
+	"github.com/m-ariany/gpt-chat-client/providers/gemini"
+	"github.com/m-ariany/gpt-chat-client/providers/ollama"
+	"github.com/m-ariany/gpt-chat-client/providers/openai"
 )
 
 const (
 	apiTimeout = time.Minute
 )
 
+// Client holds the immutable, shareable state needed to talk to an LLM
+// provider: the provider connection itself, its tokenizer, and the static
+// ClientConfig. Client is safe for concurrent use - it holds no
+// conversation history of its own, so many goroutines can drive independent
+// Conversations against the same Client concurrently. Use NewConversation or
+// ResumeConversation to start one.
 type Client struct {
-	client              *ai.Client
-	history             History
-	config              *ClientConfig
-	tokenizer           tokenizer
-	totalConsumedTokens int
+	provider  provider.Provider
+	config    *ClientConfig
+	tokenizer provider.Tokenizer
+
+	// totalConsumedTokens aggregates billConsumedTokens across every
+	// Conversation created from this Client. Accessed only via atomic.
+	totalConsumedTokens int64
+
+	defaultConvOnce sync.Once
+	defaultConv     *Conversation
+
+	// moderator and moderationMode, when set via WithModerator, take over
+	// moderation from the provider's own Moderate method and
+	// ClientConfig.ModeratePromptMessage/ModerateResponse.
+	moderator      Moderator
+	moderationMode ModerationMode
+	categoryPolicy map[string]ModerationCategoryAction
 }
 
-// NewClient instantiates a new chat client. Note that clients are not concurrency-safe. For concurrent usage,
-// it's recommended to create separate client instances.
+// NewClient instantiates a new chat client.
 func NewClient(cnf ClientConfig) (*Client, error) {
-	if len(cnf.ApiKey) == 0 {
-		return nil, fmt.Errorf("ApiKey must be present")
-	}
-
-	clientConfig := ai.DefaultConfig(cnf.ApiKey)
-	if len(cnf.ApiUrl) > 0 {
-		clientConfig.BaseURL = cnf.ApiUrl
-	} else {
-		clientConfig.BaseURL = "https://api.openai.com/v1"
-	}
-
-	tokenizer, err := newTokenzier()
+	p, err := newProvider(cnf)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Client{
-		client:    ai.NewClientWithConfig(clientConfig),
-		history:   History{},
+		provider:  p,
 		config:    &cnf,
-		tokenizer: tokenizer,
+		tokenizer: p.Tokenizer(),
 	}
 
 	if c.config.ApiTimeout == 0 {
@@ -55,12 +68,46 @@ func NewClient(cnf ClientConfig) (*Client, error) {
 	return c, nil
 }
 
+// newProvider builds the provider.Provider selected by cnf.Provider, defaulting to ProviderOpenAI.
+func newProvider(cnf ClientConfig) (provider.Provider, error) {
+	switch cnf.Provider {
+	case "", ProviderOpenAI:
+		return openai.New(openai.Config{ApiKey: cnf.ApiKey, ApiUrl: cnf.ApiUrl})
+	case ProviderAnthropic:
+		if cnf.AnthropicConfig == nil {
+			return nil, fmt.Errorf("AnthropicConfig must be present for provider %q", ProviderAnthropic)
+		}
+		return anthropic.New(*cnf.AnthropicConfig)
+	case ProviderAzureOpenAI:
+		if cnf.AzureConfig == nil {
+			return nil, fmt.Errorf("AzureConfig must be present for provider %q", ProviderAzureOpenAI)
+		}
+		return azureopenai.New(*cnf.AzureConfig)
+	case ProviderGemini:
+		if cnf.GeminiConfig == nil {
+			return nil, fmt.Errorf("GeminiConfig must be present for provider %q", ProviderGemini)
+		}
+		return gemini.New(*cnf.GeminiConfig)
+	case ProviderOllama:
+		ollamaCnf := cnf.OllamaConfig
+		if ollamaCnf == nil {
+			ollamaCnf = &ollama.Config{}
+		}
+		return ollama.New(*ollamaCnf)
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", cnf.Provider)
+	}
+}
+
 // Clone a new chat client with an empty history
 func (c *Client) Clone() *Client {
 	return &Client{
-		client:  c.client,
-		history: History{},
-		config:  c.config,
+		provider:       c.provider,
+		config:         c.config,
+		tokenizer:      c.tokenizer,
+		moderator:      c.moderator,
+		moderationMode: c.moderationMode,
+		categoryPolicy: c.categoryPolicy,
 	}
 }
 
@@ -78,328 +125,207 @@ func (c *Client) CloneWithConfig(config ClientConfig) *Client {
 	return cc
 }
 
-// Instruct sends an instruction to the client, providing system message.
-// If length of the instruction exceeds the allowed context length of the underlying model, it returns an error.
-func (c *Client) Instruct(instruction string) error {
-
-	if c.tokenizer.CountTokens(instruction) > getModel(c.config.ChatConfig.Model).MaxInstructionLength() {
-		return fmt.Errorf("max length of instruction is %d", getModel(c.config.ChatConfig.Model).MaxInstructionLength())
-	}
-
-	if len(c.history) == 0 { // insert
-		c.history = append(c.history, ai.ChatCompletionMessage{
-			Role:    ai.ChatMessageRoleSystem,
-			Content: instruction,
-		})
-	} else { // update
-		c.history[0] = ai.ChatCompletionMessage{
-			Role:    ai.ChatMessageRoleSystem,
-			Content: instruction,
-		}
+// NewConversation starts a new, empty Conversation against this Client. The
+// returned Conversation owns its own history and is safe for concurrent use
+// independently of any other Conversation created from the same Client.
+//
+// When ClientConfig.HistoryStore is set, the Conversation is also assigned a
+// fresh conversation ID and every message it appends is mirrored into the
+// store as it happens, so ResumeConversationByID can pick it back up later
+// (e.g. after a process restart).
+func (c *Client) NewConversation() *Conversation {
+	conv := &Conversation{client: c}
+
+	if c.config.HistoryStore != nil {
+		conv.id = uuid.NewString()
+		conv.store = c.config.HistoryStore
 	}
 
-	return nil
+	return conv
 }
 
-// Instruct sends an instruction to the client, providing system message.
-// If length of the instruction exceeds the allowed context length of the underlying model, it trims the instruction to fit.
-func (c *Client) InstructWithLengthFix(instruction string) {
-
-	for c.tokenizer.CountTokens(instruction) > getModel(c.config.ChatConfig.Model).MaxInstructionLength() {
-		diffToken := c.tokenizer.CountTokens(instruction) - getModel(c.config.ChatConfig.Model).MaxInstructionLength()
-		diffChar := diffToken * 3 // each token is roughly 3 latin characters
-		instruction = instruction[:len(instruction)-diffChar]
-	}
-
-	if len(c.history) == 0 { // insert
-		c.history = append(c.history, ai.ChatCompletionMessage{
-			Role:    ai.ChatMessageRoleSystem,
-			Content: instruction,
-		})
-	} else { // update
-		c.history[0] = ai.ChatCompletionMessage{
-			Role:    ai.ChatMessageRoleSystem,
-			Content: instruction,
-		}
-	}
+// ResumeConversation starts a Conversation pre-populated with history, e.g.
+// one previously obtained from Conversation.ExportHistory and persisted
+// elsewhere.
+func (c *Client) ResumeConversation(history History) *Conversation {
+	conv := c.NewConversation()
+	conv.ImportHistory(history)
+	return conv
 }
 
-// Prompt sends a prompt to the OpenAI API for generating a response.
-// It returns the generated response or an error.
-// Errors returned can be of types ErrModerationUserInput or ErrModerationModelOutput
-// if moderation flags are enabled and moderation fails, otherwise, it can be other types of errors from the underlying operations.
-func (c *Client) Prompt(ctx context.Context, prompt string) (string, error) {
-
-	if *c.config.ModeratePromptMessage {
-		err := c.moderateInput(ctx, prompt)
-		if err == ErrModeration {
-			return "", ErrModerationUserInput
-		}
-		if err != nil {
-			return "", err
-		}
+// ResumeConversationByID loads convID's history from ClientConfig.HistoryStore
+// and returns a Conversation backed by it, so a restarted service can pick up
+// a user's conversation where it left off. It requires a HistoryStore to be
+// configured; use NewConversation or ResumeConversation otherwise.
+func (c *Client) ResumeConversationByID(convID string) (*Conversation, error) {
+	if c.config.HistoryStore == nil {
+		return nil, fmt.Errorf("ResumeConversationByID requires ClientConfig.HistoryStore to be configured")
 	}
 
-	retryHandler := newRetryHandler(time.Second*5, 5)
-	var err error
-	var response string
-
-	retryHandler.Do(func() error {
-		response, err = c.prompt(ctx, prompt)
-		if err != nil {
-			log.Printf("retry calling openai %v", err)
-		}
-		return err
-	})
-
+	messages, err := c.config.HistoryStore.Load(convID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if *c.config.ModerateResponse {
-		err := c.moderateInput(ctx, response)
-		if err == ErrModeration {
-			return "", ErrModerationModelOutput
-		}
-		if err != nil {
-			return "", err
-		}
+	conv := &Conversation{
+		client:  c,
+		id:      convID,
+		store:   c.config.HistoryStore,
+		history: fromProviderMessages(messages),
 	}
+	conv.trimHistory(context.Background())
 
-	return response, nil
+	return conv, nil
 }
 
-// PromptStream sends a prompt to the OpenAI API for generating a response,
-// and returns a channel of Stream objects containing response chunks or errors.
-// The Chunk field in Stream struct contains response chunks,
-// and the Err field indicates any errors encountered during the streaming process.
-// Errors returned can be of types ErrModerationUserInput if moderation flags are enabled and moderation fails,
-// otherwise, it can be other types of errors from the underlying operations.
-//
-// Since respose is returned as stream to the client, no moderation on the response can be done in this level.
-func (c *Client) PromptStream(ctx context.Context, question string) <-chan Stream {
-
-	ch := make(chan Stream)
-
-	go func() {
-		defer close(ch)
-
-		if *c.config.ModeratePromptMessage {
-			err := c.moderateInput(ctx, question)
-			if err == ErrModeration {
-				ch <- Stream{Err: ErrModerationUserInput}
-				return
-			}
-			if err != nil {
-				ch <- Stream{Err: err}
-				return
-			}
-		}
-
-		req := c.newChatCompletionRequest(question, true)
-		ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
-		defer cancel()
-
-		stream, err := c.client.CreateChatCompletionStream(ctx, req)
-		if err != nil {
-			err = fmt.Errorf("failed to create chat completion stream %w", err)
-			ch <- Stream{Err: err}
-			return
-		}
-		defer stream.Close()
-
-		sb := strings.Builder{}
-		for {
-			data, err := stream.Recv()
-			if err != nil {
-				ch <- Stream{Err: err}
-				break
-			}
-
-			chunk := data.Choices[0].Delta.Content
-			select {
-			case ch <- Stream{Chunk: chunk}:
-			case <-ctx.Done():
-				// do not return or break as the next stream.Recv() will return error and exit the loop
-			}
-
-			sb.WriteString(chunk)
-		}
-
-		c.postStreamResponse(sb.String())
-	}()
-
-	return ch
+// ListConversations returns the IDs of every conversation held in
+// ClientConfig.HistoryStore. It requires a HistoryStore to be configured.
+func (c *Client) ListConversations() ([]string, error) {
+	if c.config.HistoryStore == nil {
+		return nil, fmt.Errorf("ListConversations requires ClientConfig.HistoryStore to be configured")
+	}
+	return c.config.HistoryStore.List()
 }
 
-// Import history to the client
-func (c *Client) ImportHistory(history History) {
-	c.history = append(c.history, history...)
-	c.trimHistory()
+// DeleteConversation removes convID from ClientConfig.HistoryStore. It
+// requires a HistoryStore to be configured.
+func (c *Client) DeleteConversation(convID string) error {
+	if c.config.HistoryStore == nil {
+		return fmt.Errorf("DeleteConversation requires ClientConfig.HistoryStore to be configured")
+	}
+	return c.config.HistoryStore.Delete(convID)
 }
 
-// Export current history of the client
-func (c *Client) ExportHistory() History {
-	return c.history
+// TotalConsumedTokens returns the aggregate number of input and output
+// tokens consumed across every Conversation created from this Client. For a
+// single conversation's own count, see Conversation.TotalConsumedTokens.
+func (c *Client) TotalConsumedTokens() int64 {
+	return atomic.LoadInt64(&c.totalConsumedTokens)
 }
 
-// Get total number of input and output tokens consumed by the client
-func (c *Client) TotalConsumedTokens() int {
-	return c.totalConsumedTokens
+// billConsumedTokens atomically adds n to the Client-wide aggregate.
+func (c *Client) billConsumedTokens(n int) {
+	atomic.AddInt64(&c.totalConsumedTokens, int64(n))
 }
 
-func (c *Client) prompt(ctx context.Context, question string) (string, error) {
+// defaultConversation lazily creates the single implicit Conversation backing
+// Client's deprecated history-bearing methods.
+func (c *Client) defaultConversation() *Conversation {
+	c.defaultConvOnce.Do(func() {
+		c.defaultConv = c.NewConversation()
+	})
+	return c.defaultConv
+}
 
-	req := c.newChatCompletionRequest(question, false)
-	ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
-	defer cancel()
-	resp, err := c.client.CreateChatCompletion(ctx, req)
-	if err != nil {
-		err = fmt.Errorf("failed to create chat completion %w", err)
-		return "", err
-	}
+// Instruct sends an instruction to the client, providing system message.
+// If length of the instruction exceeds the allowed context length of the underlying model, it returns an error.
+//
+// Deprecated: use NewConversation and Conversation.Instruct instead. This
+// method operates on an implicit default Conversation shared by all of
+// Client's deprecated wrapper methods.
+func (c *Client) Instruct(instruction string) error {
+	return c.defaultConversation().Instruct(instruction)
+}
 
-	data := resp.Choices[0].Message.Content
-	c.billConsumedTokens(resp.Usage.TotalTokens)
-	return data, nil
+// InstructWithLengthFix sends an instruction to the client, providing system message.
+// If length of the instruction exceeds the allowed context length of the underlying model, it trims the instruction to fit.
+//
+// Deprecated: use NewConversation and Conversation.InstructWithLengthFix instead.
+func (c *Client) InstructWithLengthFix(instruction string) {
+	c.defaultConversation().InstructWithLengthFix(instruction)
 }
 
-func (c *Client) newChatCompletionRequest(question string, stream bool) ai.ChatCompletionRequest {
+// Prompt sends a prompt to the underlying provider for generating a response.
+//
+// Deprecated: use NewConversation and Conversation.Prompt instead.
+func (c *Client) Prompt(ctx context.Context, prompt string) (string, error) {
+	return c.defaultConversation().Prompt(ctx, prompt)
+}
 
-	/*
-		Ref: https://platform.openai.com/docs/guides/chat/introduction
-		Including the conversation history helps the models to give relevant answers to the prior conversation.
-		Because the models have no memory of past requests, all relevant information must be supplied via the conversation.
-	*/
-	c.history = append(c.history, ai.ChatCompletionMessage{
-		Role:    ai.ChatMessageRoleUser,
-		Content: question,
-	})
+// PromptStream sends a prompt to the underlying provider for generating a response,
+// and returns a channel of Stream objects containing response chunks or errors.
+//
+// Deprecated: use NewConversation and Conversation.PromptStream instead.
+func (c *Client) PromptStream(ctx context.Context, question string) <-chan Stream {
+	return c.defaultConversation().PromptStream(ctx, question)
+}
 
-	c.trimHistory()
-
-	request := ai.ChatCompletionRequest{
-		Model:            c.config.ChatConfig.Model,
-		Messages:         c.history,
-		Temperature:      c.config.ChatConfig.Temperature,
-		MaxTokens:        c.config.ChatConfig.MaxTokens,
-		TopP:             c.config.ChatConfig.TopP,
-		N:                c.config.ChatConfig.N,
-		Stop:             c.config.ChatConfig.Stop,
-		PresencePenalty:  c.config.ChatConfig.PresencePenalty,
-		ResponseFormat:   c.config.ChatConfig.ResponseFormat,
-		Seed:             c.config.ChatConfig.Seed,
-		FrequencyPenalty: c.config.ChatConfig.PresencePenalty,
-		LogitBias:        c.config.ChatConfig.LogitBias,
-		User:             c.config.ChatConfig.User,
-		Tools:            c.config.ChatConfig.Tools,
-		ToolChoice:       c.config.ChatConfig.ToolChoice,
-		Stream:           stream,
-	}
+// PromptWithTools sends a prompt to the underlying provider, dispatching any tool calls through registry.
+//
+// Deprecated: use NewConversation and Conversation.PromptWithTools instead.
+func (c *Client) PromptWithTools(ctx context.Context, prompt string, registry *ToolRegistry) (string, error) {
+	return c.defaultConversation().PromptWithTools(ctx, prompt, registry)
+}
 
-	return request
+// PromptStreamWithTools behaves like PromptWithTools, delivering the final response over the returned channel.
+//
+// Deprecated: use NewConversation and Conversation.PromptStreamWithTools instead.
+func (c *Client) PromptStreamWithTools(ctx context.Context, prompt string, registry *ToolRegistry) <-chan Stream {
+	return c.defaultConversation().PromptStreamWithTools(ctx, prompt, registry)
 }
 
-// Trim history to fit the maximum number of tokens or messages allowed.
-func (c *Client) trimHistory() {
+// ImportHistory appends history to the client.
+//
+// Deprecated: use NewConversation and Conversation.ImportHistory instead.
+func (c *Client) ImportHistory(history History) {
+	c.defaultConversation().ImportHistory(history)
+}
 
-	if c.config.MemoryTokenSize != nil {
-		c.trimHistoryToMatchTokenLimit(*c.config.MemoryTokenSize)
-	}
+// ExportHistory returns the current history of the client.
+//
+// Deprecated: use NewConversation and Conversation.ExportHistory instead.
+func (c *Client) ExportHistory() History {
+	return c.defaultConversation().ExportHistory()
+}
 
-	if c.config.MemoryMessageSize != nil {
-		c.trimHistoryToMatchMessageLimit()
+// FineTuneClient returns a finetune.Client reusing this Client's OpenAI
+// credentials, so callers don't have to configure and authenticate a second,
+// disconnected client by hand. Fine-tuning is an OpenAI-only API, so this
+// requires ClientConfig.Provider to be ProviderOpenAI (the default).
+func (c *Client) FineTuneClient() (*finetune.Client, error) {
+	if c.config.Provider != "" && c.config.Provider != ProviderOpenAI {
+		return nil, fmt.Errorf("FineTuneClient requires ClientConfig.Provider to be %q, got %q", ProviderOpenAI, c.config.Provider)
 	}
 
-	// to make sure that the remained context does not exceed the allowed model's context length
-	c.trimHistoryToMatchTokenLimit(getModel(c.config.ChatConfig.Model).ContextLength())
+	return finetune.New(finetune.Config{ApiKey: c.config.ApiKey, ApiUrl: c.config.ApiUrl})
 }
 
-func (c *Client) trimHistoryToMatchTokenLimit(limit int) error {
-	// there is only a system message
-	if len(c.history) == 1 {
-		return nil
-	}
-
-	// exclude instruction from the operation
-	historyToString := func() (string, error) {
-		return c.history[1:].ToString()
+// moderateInput checks input against c's configured Moderator (see
+// WithModerator), or the provider's own Moderate method if none was
+// configured. It returns ErrModeration if the content is flagged (after
+// categoryPolicy overrides, in the WithModerator case), or any error
+// encountered calling the moderation backend.
+func (c *Client) moderateInput(ctx context.Context, input string) error {
+	if c.moderator != nil {
+		return c.moderate(ctx, input)
 	}
 
-	historyAsString, err := historyToString()
+	flagged, err := c.provider.Moderate(ctx, input)
 	if err != nil {
 		return err
 	}
 
-	for c.tokenizer.CountTokens(historyAsString) > limit {
-		// only system message and one additional message is remained.
-		// delete the additional message.
-		if len(c.history) == 2 {
-			c.history = c.history[:1]
-			break
-		}
-
-		// shave the oldest messages first
-		copy(c.history[1:], c.history[2:])
-		c.history = c.history[:len(c.history)-1]
-
-		if historyAsString, err = historyToString(); err != nil {
-			return err
-		}
+	if flagged {
+		return ErrModeration
 	}
 
 	return nil
 }
 
-func (c *Client) trimHistoryToMatchMessageLimit() {
-	memorySize := *c.config.MemoryMessageSize
-	// exclude instruction from the operation
-	if len(c.history)-1 <= memorySize {
-		return
+// shouldModerateInput reports whether the user's prompt should be run
+// through moderateInput before being sent to the provider.
+func (c *Client) shouldModerateInput() bool {
+	if c.moderator != nil {
+		return c.moderationMode == ModerationInputOnly || c.moderationMode == ModerationBoth
 	}
-	// shave the oldest messages first
-	c.history = append(c.history[:1], c.history[1+len(c.history)-memorySize:]...)
+	return *c.config.ModeratePromptMessage
 }
 
-func (c *Client) postStreamResponse(r string) {
-	if len(r) == 0 {
-		return
+// shouldModerateOutput reports whether the assistant's response should be
+// run through moderateInput before being returned to the caller.
+func (c *Client) shouldModerateOutput() bool {
+	if c.moderator != nil {
+		return c.moderationMode == ModerationOutputOnly || c.moderationMode == ModerationBoth
 	}
-
-	c.history = append(c.history, ai.ChatCompletionMessage{
-		Role:    ai.ChatMessageRoleAssistant,
-		Content: r,
-	})
-
-	history, err := c.history.ToString()
-	if err != nil {
-		log.Println("failed to bill consumed tokens")
-	}
-	n := c.tokenizer.CountTokens(history)
-	c.billConsumedTokens(n)
-}
-
-func (c *Client) billConsumedTokens(n int) {
-	c.totalConsumedTokens += n
-}
-
-// moderateInput sends the input string to the OpenAI API for moderation.
-// It returns an error if there's an issue with the API call or if the input is flagged for moderation.
-// Otherwise, it returns nil.
-func (c *Client) moderateInput(ctx context.Context, input string) error {
-
-	result, err := c.client.Moderations(ctx, ai.ModerationRequest{
-		Input: input,
-		Model: ai.ModerationTextStable,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	if result.Results[0].Flagged {
-		return ErrModeration
-	}
-
-	return nil
+	return *c.config.ModerateResponse
 }
@@ -0,0 +1,739 @@
+package chatclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/historystore"
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// Conversation holds one conversation's mutable state (history and token
+// accounting) against the immutable, shared Client it was created from.
+// A Conversation is safe for concurrent use: its own state is guarded by a
+// mutex, so many goroutines can drive independent Conversations against one
+// Client while sharing its provider connection and token accounting.
+type Conversation struct {
+	mu                  sync.Mutex
+	client              *Client
+	history             History
+	totalConsumedTokens int
+
+	// id and store are set when this Conversation is backed by a
+	// ClientConfig.HistoryStore; every message appended to history is then
+	// mirrored into the store under id. Both are zero for the common,
+	// in-memory-only case.
+	id    string
+	store historystore.Store
+
+	// hasSummary and pendingEvictions support ClientConfig.MemoryStrategy's
+	// Summarize/Hybrid modes. hasSummary reports whether history[1] is
+	// currently a synthetic rolling summary (see trimHistoryToMatchTokenLimit);
+	// pendingEvictions accumulates messages Hybrid has evicted but not yet
+	// folded into that summary.
+	hasSummary       bool
+	pendingEvictions History
+}
+
+// ID returns the conversation ID this Conversation is persisted under, or ""
+// if it isn't backed by a HistoryStore.
+func (conv *Conversation) ID() string {
+	return conv.id
+}
+
+// persist mirrors msgs into conv's HistoryStore, if one is configured. A
+// failure to persist is logged rather than returned, since the in-memory
+// conversation itself (the return value callers actually depend on) already
+// succeeded. Callers must hold conv.mu.
+func (conv *Conversation) persist(msgs ...ai.ChatCompletionMessage) {
+	if conv.store == nil {
+		return
+	}
+
+	for _, m := range msgs {
+		if err := conv.store.Append(conv.id, toProviderMessage(m)); err != nil {
+			log.Printf("historystore: failed to persist message for conversation %q: %v", conv.id, err)
+		}
+	}
+}
+
+// Fork copies conv's current history into a new Conversation under a fresh
+// ID, leaving conv itself untouched. If conv is backed by a HistoryStore, the
+// fork is persisted under its own ID in the same store; otherwise the fork is
+// an in-memory copy, same as conv.
+func (conv *Conversation) Fork() *Conversation {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	fork := conv.client.NewConversation()
+	fork.history = append(History{}, conv.history...)
+	fork.persist(fork.history...)
+
+	return fork
+}
+
+// Instruct sends an instruction to the conversation, providing a system message.
+// If length of the instruction exceeds the allowed context length of the underlying model, it returns an error.
+// Providers without a registered model (e.g. non-OpenAI backends) skip the length check entirely.
+func (conv *Conversation) Instruct(instruction string) error {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	c := conv.client
+	if m := getModel(c.config.ChatConfig.Model); m != nil {
+		if c.tokenizer.CountTokens(instruction) > m.MaxInstructionLength() {
+			return fmt.Errorf("max length of instruction is %d", m.MaxInstructionLength())
+		}
+	}
+
+	conv.setInstruction(instruction)
+
+	return nil
+}
+
+// InstructWithLengthFix sends an instruction to the conversation, providing a system message.
+// If length of the instruction exceeds the allowed context length of the underlying model, it trims the instruction to fit.
+// Providers without a registered model (e.g. non-OpenAI backends) skip the length fix entirely, since there's no
+// known limit to fix the instruction to.
+func (conv *Conversation) InstructWithLengthFix(instruction string) {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	c := conv.client
+	if m := getModel(c.config.ChatConfig.Model); m != nil {
+		for c.tokenizer.CountTokens(instruction) > m.MaxInstructionLength() {
+			diffToken := c.tokenizer.CountTokens(instruction) - m.MaxInstructionLength()
+			diffChar := diffToken * 3 // each token is roughly 3 latin characters
+			instruction = instruction[:len(instruction)-diffChar]
+		}
+	}
+
+	conv.setInstruction(instruction)
+}
+
+// setInstruction inserts or updates the leading message of the history with the
+// given instruction. The o1/o3 reasoning models reject a system-role message in
+// the current beta, so for those models the instruction is kept as a user-role
+// message instead. Callers must hold conv.mu.
+func (conv *Conversation) setInstruction(instruction string) {
+
+	role := ai.ChatMessageRoleSystem
+	if isReasoningModel(conv.client.config.ChatConfig.Model) {
+		role = ai.ChatMessageRoleUser
+	}
+
+	if len(conv.history) == 0 { // insert
+		msg := ai.ChatCompletionMessage{Role: role, Content: instruction}
+		conv.history = append(conv.history, msg)
+		conv.persist(msg)
+	} else { // update
+		// A HistoryStore is append-only, so an instruction change after the
+		// first message has already been persisted is not re-persisted;
+		// ResumeConversationByID will replay the original instruction.
+		conv.history[0] = ai.ChatCompletionMessage{
+			Role:    role,
+			Content: instruction,
+		}
+	}
+}
+
+// Prompt sends a prompt to the underlying provider for generating a response.
+// It returns the generated response or an error.
+// Errors returned can be of types ErrModerationUserInput or ErrModerationModelOutput
+// if moderation flags are enabled and moderation fails, otherwise, it can be other types of errors from the underlying operations.
+func (conv *Conversation) Prompt(ctx context.Context, prompt string) (string, error) {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	c := conv.client
+
+	if c.shouldModerateInput() {
+		err := c.moderateInput(ctx, prompt)
+		if err == ErrModeration {
+			return "", ErrModerationUserInput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	retryHandler := newRetryHandler(time.Second*5, 5)
+	var err error
+	var response string
+
+	retryHandler.Do(func() error {
+		response, err = conv.prompt(ctx, prompt)
+		if err != nil {
+			log.Printf("retry calling provider %v", err)
+		}
+		return err
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if c.shouldModerateOutput() {
+		err := c.moderateInput(ctx, response)
+		if err == ErrModeration {
+			return "", ErrModerationModelOutput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return response, nil
+}
+
+// PromptStream sends a prompt to the underlying provider for generating a response,
+// and returns a channel of Stream objects containing response chunks or errors.
+// The Chunk field in Stream struct contains response chunks,
+// and the Err field indicates any errors encountered during the streaming process.
+// Errors returned can be of types ErrModerationUserInput if moderation flags are enabled and moderation fails,
+// otherwise, it can be other types of errors from the underlying operations.
+//
+// Since respose is returned as stream to the client, no moderation on the response can be done in this level.
+func (conv *Conversation) PromptStream(ctx context.Context, question string) <-chan Stream {
+
+	ch := make(chan Stream)
+
+	go func() {
+		defer close(ch)
+
+		conv.mu.Lock()
+		defer conv.mu.Unlock()
+
+		c := conv.client
+
+		if c.shouldModerateInput() {
+			err := c.moderateInput(ctx, question)
+			if err == ErrModeration {
+				ch <- Stream{Err: ErrModerationUserInput}
+				return
+			}
+			if err != nil {
+				ch <- Stream{Err: err}
+				return
+			}
+		}
+
+		req, err := conv.newChatCompletionRequest(ctx, question, true)
+		if err != nil {
+			ch <- Stream{Err: err}
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+		defer cancel()
+
+		if !req.Stream {
+			// LenientFieldValidation stripped streaming for a reasoning model
+			// that rejects it; fall back to a single non-streaming completion
+			// delivered as one chunk instead.
+			resp, err := c.provider.CreateChatCompletion(ctx, req)
+			if err != nil {
+				ch <- Stream{Err: err}
+				return
+			}
+			ch <- Stream{Chunk: resp.Content}
+			conv.postStreamResponse(resp.Content, &resp.Usage)
+			return
+		}
+
+		stream, err := c.provider.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			ch <- Stream{Err: err}
+			return
+		}
+
+		sb := strings.Builder{}
+		var usage *provider.Usage
+		for chunk := range stream {
+			if chunk.Err != nil {
+				ch <- Stream{Err: chunk.Err}
+				break
+			}
+
+			select {
+			case ch <- Stream{Chunk: chunk.Content}:
+			case <-ctx.Done():
+				// do not return or break as the stream will close and the range loop will exit
+			}
+
+			sb.WriteString(chunk.Content)
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+		}
+
+		conv.postStreamResponse(sb.String(), usage)
+	}()
+
+	return ch
+}
+
+// ImportHistory appends history to the conversation.
+func (conv *Conversation) ImportHistory(history History) {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	conv.history = append(conv.history, history...)
+	conv.persist(history...)
+	// ImportHistory has no caller-supplied ctx to thread through a possible
+	// Summarize/Hybrid round-trip; it falls back to context.Background(),
+	// still bounded by ClientConfig.ApiTimeout.
+	conv.trimHistory(context.Background())
+}
+
+// ExportHistory returns the current history of the conversation.
+func (conv *Conversation) ExportHistory() History {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	return conv.history
+}
+
+// TotalConsumedTokens returns the number of input and output tokens this
+// conversation alone has consumed. For the aggregate across every
+// conversation driven by the same Client, see Client.TotalConsumedTokens.
+func (conv *Conversation) TotalConsumedTokens() int {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	return conv.totalConsumedTokens
+}
+
+// prompt issues one completion round-trip. Callers must hold conv.mu.
+func (conv *Conversation) prompt(ctx context.Context, question string) (string, error) {
+
+	c := conv.client
+
+	req, err := conv.newChatCompletionRequest(ctx, question, false)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+	defer cancel()
+	resp, err := c.provider.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleAssistant, Content: resp.Content}
+	conv.history = append(conv.history, msg)
+	conv.persist(msg)
+	conv.billConsumedTokens(resp.Usage.TotalTokens)
+	return resp.Content, nil
+}
+
+// newChatCompletionRequest appends question to the history and builds the
+// provider-agnostic request for it. Callers must hold conv.mu.
+func (conv *Conversation) newChatCompletionRequest(ctx context.Context, question string, stream bool) (provider.ChatRequest, error) {
+
+	c := conv.client
+
+	/*
+		Ref: https://platform.openai.com/docs/guides/chat/introduction
+		Including the conversation history helps the models to give relevant answers to the prior conversation.
+		Because the models have no memory of past requests, all relevant information must be supplied via the conversation.
+	*/
+	msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleUser, Content: question}
+	conv.history = append(conv.history, msg)
+	conv.persist(msg)
+
+	conv.trimHistory(ctx)
+
+	model := c.config.ChatConfig.Model
+	request := provider.ChatRequest{
+		Model:            model,
+		Messages:         toProviderMessages(conv.history),
+		Temperature:      c.config.ChatConfig.Temperature,
+		MaxTokens:        c.config.ChatConfig.MaxTokens,
+		TopP:             c.config.ChatConfig.TopP,
+		Stop:             c.config.ChatConfig.Stop,
+		PresencePenalty:  c.config.ChatConfig.PresencePenalty,
+		FrequencyPenalty: c.config.ChatConfig.FrequencyPenalty,
+		LogitBias:        c.config.ChatConfig.LogitBias,
+		N:                c.config.ChatConfig.N,
+		Seed:             c.config.ChatConfig.Seed,
+		User:             c.config.ChatConfig.User,
+		Stream:           stream,
+		Tools:            toProviderTools(c.config.ChatConfig.Tools),
+		ToolChoice:       toProviderToolChoice(c.config.ChatConfig.ToolChoice),
+		ReasoningModel:   isReasoningModel(model),
+		ReasoningEffort:  c.config.ChatConfig.ReasoningEffort,
+	}
+
+	if err := c.applyReasoningConstraints(&request); err != nil {
+		return provider.ChatRequest{}, err
+	}
+
+	return request, nil
+}
+
+// toProviderMessages converts History to the provider-agnostic message list,
+// carrying over tool calls and tool_call_id so tool-calling conversations
+// round-trip correctly through the provider abstraction.
+func toProviderMessages(history History) []provider.Message {
+	messages := make([]provider.Message, len(history))
+	for i, m := range history {
+		toolCalls := make([]provider.ToolCall, len(m.ToolCalls))
+		for j, tc := range m.ToolCalls {
+			toolCalls[j] = provider.ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments}
+		}
+
+		messages[i] = provider.Message{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  toolCalls,
+			ToolCallID: m.ToolCallID,
+		}
+	}
+
+	return messages
+}
+
+// toProviderMessage converts a single message, as toProviderMessages does for
+// a whole History.
+func toProviderMessage(m ai.ChatCompletionMessage) provider.Message {
+	toolCalls := make([]provider.ToolCall, len(m.ToolCalls))
+	for j, tc := range m.ToolCalls {
+		toolCalls[j] = provider.ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments}
+	}
+
+	return provider.Message{
+		Role:       m.Role,
+		Content:    m.Content,
+		ToolCalls:  toolCalls,
+		ToolCallID: m.ToolCallID,
+	}
+}
+
+// fromProviderMessages converts a HistoryStore's provider-agnostic messages
+// back into History, the inverse of toProviderMessages.
+func fromProviderMessages(messages []provider.Message) History {
+	history := make(History, len(messages))
+	for i, m := range messages {
+		toolCalls := make([]ai.ToolCall, len(m.ToolCalls))
+		for j, tc := range m.ToolCalls {
+			toolCalls[j] = ai.ToolCall{
+				ID:       tc.ID,
+				Type:     ai.ToolTypeFunction,
+				Function: ai.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+			}
+		}
+
+		history[i] = ai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  toolCalls,
+			ToolCallID: m.ToolCallID,
+		}
+	}
+
+	return history
+}
+
+// toProviderTools converts the OpenAI-shaped ChatConfig.Tools into the
+// provider-agnostic Tool list.
+func toProviderTools(tools []ai.Tool) []provider.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := make([]provider.Tool, 0, len(tools))
+	for _, t := range tools {
+		if t.Function == nil {
+			continue
+		}
+		out = append(out, provider.Tool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+
+	return out
+}
+
+// toProviderToolChoice converts the OpenAI-shaped ChatConfig.ToolChoice into
+// the provider-agnostic string form. Only the "auto"/"none"/"required" string
+// form is supported; anything else (e.g. a specific-function ToolChoice
+// struct) is left for the provider's default behavior.
+func toProviderToolChoice(choice any) string {
+	if s, ok := choice.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// trimHistory trims history to fit the maximum number of tokens or messages
+// allowed. Callers must hold conv.mu.
+//
+// ctx bounds any summarization round-trip triggered by ClientConfig's
+// Summarize/Hybrid MemoryStrategy (see trimHistoryToMatchTokenLimit). Callers
+// without a caller-supplied ctx of their own (ImportHistory,
+// ResumeConversationByID) pass context.Background(), bounded only by
+// ClientConfig.ApiTimeout.
+func (conv *Conversation) trimHistory(ctx context.Context) {
+
+	c := conv.client
+
+	if c.config.MemoryTokenSize != nil {
+		conv.trimHistoryToMatchTokenLimit(ctx, *c.config.MemoryTokenSize)
+	}
+
+	if c.config.MemoryMessageSize != nil {
+		conv.trimHistoryToMatchMessageLimit()
+	}
+
+	// to make sure that the remained context does not exceed the allowed model's context length,
+	// when known. Providers without a registered model (e.g. non-OpenAI backends) skip this step.
+	if m := getModel(c.config.ChatConfig.Model); m != nil {
+		conv.trimHistoryToMatchTokenLimit(ctx, m.ContextLength())
+	}
+
+	conv.trimStore()
+}
+
+// trimStore mirrors an in-memory history trim into conv's HistoryStore, if
+// one is configured, so persisted history doesn't diverge from (and grow
+// unbounded relative to) what trimHistory just kept in memory. Like persist,
+// a failure is logged rather than returned. Callers must hold conv.mu.
+func (conv *Conversation) trimStore() {
+	if conv.store == nil {
+		return
+	}
+
+	policy := historystore.TrimPolicy{Keep: len(conv.history)}
+	if err := conv.store.Trim(conv.id, policy); err != nil {
+		log.Printf("historystore: failed to trim conversation %q: %v", conv.id, err)
+	}
+}
+
+// trimHistoryToMatchTokenLimit evicts the oldest messages (excluding the
+// instruction) until history fits limit tokens. What happens to the evicted
+// messages depends on ClientConfig.MemoryStrategy:
+//   - DropOldest (the default): they are simply discarded.
+//   - Summarize: they are folded into a synthetic role:"system" summary
+//     message kept right after the instruction.
+//   - Hybrid: they are discarded, except every SummaryEveryNEvictions
+//     messages are instead folded into the running summary - amortizing the
+//     cost of summarization against the cost of losing older context.
+func (conv *Conversation) trimHistoryToMatchTokenLimit(ctx context.Context, limit int) error {
+	// head is the number of leading messages eviction must never touch: the
+	// instruction, plus the rolling summary message once one exists.
+	head := 1
+	if conv.hasSummary {
+		head = 2
+	}
+
+	if len(conv.history) <= head {
+		return nil
+	}
+
+	// exclude instruction from the operation
+	historyToString := func() (string, error) {
+		return conv.history[1:].ToString()
+	}
+
+	historyAsString, err := historyToString()
+	if err != nil {
+		return err
+	}
+
+	var evicted History
+	for conv.client.tokenizer.CountTokens(historyAsString) > limit {
+		// only head and one additional message are remained. delete the
+		// additional message.
+		if len(conv.history) == head+1 {
+			evicted = append(evicted, conv.history[head])
+			conv.history = conv.history[:head]
+			break
+		}
+
+		// shave the oldest messages first
+		evicted = append(evicted, conv.history[head])
+		copy(conv.history[head:], conv.history[head+1:])
+		conv.history = conv.history[:len(conv.history)-1]
+
+		if historyAsString, err = historyToString(); err != nil {
+			return err
+		}
+	}
+
+	if len(evicted) > 0 {
+		conv.handleEvictions(ctx, evicted)
+	}
+
+	return nil
+}
+
+// defaultSummaryEveryNEvictions is used by Hybrid when
+// ClientConfig.SummaryEveryNEvictions is unset.
+const defaultSummaryEveryNEvictions = 5
+
+// handleEvictions decides what becomes of messages trimHistoryToMatchTokenLimit
+// just evicted, per ClientConfig.MemoryStrategy. Callers must hold conv.mu.
+func (conv *Conversation) handleEvictions(ctx context.Context, evicted History) {
+	switch conv.client.config.MemoryStrategy {
+	case Summarize:
+		conv.summarize(ctx, evicted)
+	case Hybrid:
+		conv.pendingEvictions = append(conv.pendingEvictions, evicted...)
+
+		n := conv.client.config.SummaryEveryNEvictions
+		if n <= 0 {
+			n = defaultSummaryEveryNEvictions
+		}
+		if len(conv.pendingEvictions) >= n {
+			conv.summarize(ctx, conv.pendingEvictions)
+			conv.pendingEvictions = nil
+		}
+	default: // DropOldest
+	}
+}
+
+// summarize folds window into the conversation's rolling summary message
+// (history[1], right after the instruction), creating it if this is the
+// first summarization. If the resulting summary itself exceeds
+// ClientConfig.SummaryTokenSize, it is condensed once more against just
+// itself. Failures are logged, not returned: falling back to having simply
+// dropped the evicted messages (DropOldest's behavior) is preferable to
+// failing the prompt that triggered trimming. Callers must hold conv.mu.
+func (conv *Conversation) summarize(ctx context.Context, window History) {
+	if len(window) == 0 {
+		return
+	}
+
+	if conv.hasSummary {
+		window = append(History{conv.history[1]}, window...)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		text, err := window.ToString()
+		if err != nil {
+			log.Printf("memory: failed to stringify history for summarization: %v", err)
+			return
+		}
+
+		summary, err := conv.requestSummary(ctx, text)
+		if err != nil {
+			log.Printf("memory: failed to summarize evicted history: %v", err)
+			return
+		}
+
+		msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleSystem, Content: summary}
+		if conv.hasSummary {
+			conv.history[1] = msg
+		} else {
+			conv.history = append(History{conv.history[0], msg}, conv.history[1:]...)
+			conv.hasSummary = true
+		}
+
+		budget := conv.client.config.SummaryTokenSize
+		if budget == nil || conv.client.tokenizer.CountTokens(summary) <= *budget {
+			return
+		}
+
+		// The summary itself outgrew its own budget; fold it back in and
+		// ask the model to condense it further, once.
+		window = History{msg}
+	}
+}
+
+// summaryPromptPrefix precedes the evicted window text sent to SummaryModel.
+const summaryPromptPrefix = "Summarize the following conversation history concisely, preserving any facts, decisions and open questions a later turn might still need:\n\n"
+
+// requestSummary asks ClientConfig.SummaryModel (or ChatConfig.Model if unset)
+// to summarize text, billing the round-trip's token usage against conv like
+// any other completion. Callers must hold conv.mu.
+func (conv *Conversation) requestSummary(ctx context.Context, text string) (string, error) {
+	c := conv.client
+
+	model := c.config.SummaryModel
+	if model == "" {
+		model = c.config.ChatConfig.Model
+	}
+
+	req := provider.ChatRequest{
+		Model: model,
+		Messages: []provider.Message{
+			{Role: ai.ChatMessageRoleUser, Content: summaryPromptPrefix + text},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+	defer cancel()
+
+	retryHandler := newRetryHandler(time.Second*5, 5)
+	var resp provider.ChatResponse
+	var err error
+	retryHandler.Do(func() error {
+		resp, err = c.provider.CreateChatCompletion(ctx, req)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	conv.billConsumedTokens(resp.Usage.TotalTokens)
+	return resp.Content, nil
+}
+
+func (conv *Conversation) trimHistoryToMatchMessageLimit() {
+	// head is the number of leading messages eviction must never touch: the
+	// instruction, plus the rolling summary message once one exists (see
+	// trimHistoryToMatchTokenLimit).
+	head := 1
+	if conv.hasSummary {
+		head = 2
+	}
+
+	memorySize := *conv.client.config.MemoryMessageSize
+	// exclude head from the operation
+	if len(conv.history)-head <= memorySize {
+		return
+	}
+	// shave the oldest messages first
+	conv.history = append(conv.history[:head], conv.history[head+len(conv.history)-memorySize:]...)
+}
+
+// postStreamResponse appends the assembled streamed response r to history and
+// bills the tokens it consumed. When usage is provided by the backend (not
+// every provider reports usage for a streamed completion), it is billed
+// directly instead of falling back to conv's own tokenizer estimate.
+func (conv *Conversation) postStreamResponse(r string, usage *provider.Usage) {
+	if len(r) == 0 {
+		return
+	}
+
+	msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleAssistant, Content: r}
+	conv.history = append(conv.history, msg)
+	conv.persist(msg)
+
+	if usage != nil {
+		conv.billConsumedTokens(usage.TotalTokens)
+		return
+	}
+
+	history, err := conv.history.ToString()
+	if err != nil {
+		log.Println("failed to bill consumed tokens")
+	}
+	n := conv.client.tokenizer.CountTokens(history)
+	conv.billConsumedTokens(n)
+}
+
+// billConsumedTokens records n tokens against both this conversation and the
+// shared Client's aggregate counter. Callers must hold conv.mu.
+func (conv *Conversation) billConsumedTokens(n int) {
+	conv.totalConsumedTokens += n
+	conv.client.billConsumedTokens(n)
+}
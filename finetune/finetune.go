@@ -0,0 +1,224 @@
+// Package finetune exposes OpenAI's fine-tuning-jobs API: creating,
+// retrieving, cancelling and listing jobs, plus a WaitForJob poller for
+// callers who just want to block until a job finishes.
+package finetune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/internal/retry"
+)
+
+// Terminal job statuses, per https://platform.openai.com/docs/api-reference/fine-tuning/object.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Config holds the settings needed to talk to the OpenAI fine-tuning API.
+type Config struct {
+	// ApiKey is the authentication key required to access the OpenAI API.
+	ApiKey string
+
+	// ApiUrl overrides the default OpenAI API base URL.
+	ApiUrl string
+}
+
+// Client manages fine-tuning jobs against the OpenAI API.
+type Client struct {
+	client  *ai.Client
+	apiKey  string
+	baseURL string
+}
+
+// New builds a finetune Client from cnf.
+func New(cnf Config) (*Client, error) {
+	if len(cnf.ApiKey) == 0 {
+		return nil, fmt.Errorf("ApiKey must be present")
+	}
+
+	baseURL := "https://api.openai.com/v1"
+	if len(cnf.ApiUrl) > 0 {
+		baseURL = cnf.ApiUrl
+	}
+
+	clientConfig := ai.DefaultConfig(cnf.ApiKey)
+	clientConfig.BaseURL = baseURL
+
+	return &Client{
+		client:  ai.NewClientWithConfig(clientConfig),
+		apiKey:  cnf.ApiKey,
+		baseURL: baseURL,
+	}, nil
+}
+
+// CreateFineTuningJob creates a fine-tuning job, backing off and retrying transient 5xx/429 responses.
+func (c *Client) CreateFineTuningJob(ctx context.Context, request ai.FineTuningJobRequest) (ai.FineTuningJob, error) {
+	var resp ai.FineTuningJob
+	var err error
+
+	retryHandler := retry.New(time.Second*5, 5)
+	retryHandler.Do(func() error {
+		resp, err = c.client.CreateFineTuningJob(ctx, request)
+		return err
+	})
+
+	return resp, err
+}
+
+// RetrieveFineTuningJob fetches the current state of a fine-tuning job.
+func (c *Client) RetrieveFineTuningJob(ctx context.Context, id string) (ai.FineTuningJob, error) {
+	var resp ai.FineTuningJob
+	var err error
+
+	retryHandler := retry.New(time.Second*5, 5)
+	retryHandler.Do(func() error {
+		resp, err = c.client.RetrieveFineTuningJob(ctx, id)
+		return err
+	})
+
+	return resp, err
+}
+
+// CancelFineTuningJob cancels an in-progress fine-tuning job.
+func (c *Client) CancelFineTuningJob(ctx context.Context, id string) (ai.FineTuningJob, error) {
+	var resp ai.FineTuningJob
+	var err error
+
+	retryHandler := retry.New(time.Second*5, 5)
+	retryHandler.Do(func() error {
+		resp, err = c.client.CancelFineTuningJob(ctx, id)
+		return err
+	})
+
+	return resp, err
+}
+
+// ListFineTuningJobEvents lists the status events emitted by a fine-tuning job.
+func (c *Client) ListFineTuningJobEvents(ctx context.Context, id string, params ListParams) (ai.FineTuningJobEventList, error) {
+	var setters []ai.ListFineTuningJobEventsParameter
+	if params.After != "" {
+		setters = append(setters, ai.ListFineTuningJobEventsWithAfter(params.After))
+	}
+	if params.Limit != 0 {
+		setters = append(setters, ai.ListFineTuningJobEventsWithLimit(params.Limit))
+	}
+
+	var resp ai.FineTuningJobEventList
+	var err error
+
+	retryHandler := retry.New(time.Second*5, 5)
+	retryHandler.Do(func() error {
+		resp, err = c.client.ListFineTuningJobEvents(ctx, id, setters...)
+		return err
+	})
+
+	return resp, err
+}
+
+// ListParams paginates a fine-tuning list endpoint.
+type ListParams struct {
+	After string
+	Limit int
+}
+
+// FineTuningJobList is the paginated response from ListFineTuningJobs.
+type FineTuningJobList struct {
+	Object  string             `json:"object"`
+	Data    []ai.FineTuningJob `json:"data"`
+	HasMore bool               `json:"has_more"`
+}
+
+// ListFineTuningJobs lists fine-tuning jobs. go-openai does not expose this
+// endpoint, so it is called directly over HTTP, mirroring the raw-HTTP
+// approach the anthropic provider uses for endpoints outside an SDK's coverage.
+func (c *Client) ListFineTuningJobs(ctx context.Context, params ListParams) (FineTuningJobList, error) {
+
+	url := c.baseURL + "/fine_tuning/jobs"
+	if q := params.query(); q != "" {
+		url += "?" + q
+	}
+
+	var resp FineTuningJobList
+	var err error
+
+	retryHandler := retry.New(time.Second*5, 5)
+	retryHandler.Do(func() error {
+		resp, err = c.listFineTuningJobs(ctx, url)
+		return err
+	})
+
+	return resp, err
+}
+
+func (p ListParams) query() string {
+	q := ""
+	if p.After != "" {
+		q += "after=" + p.After
+	}
+	if p.Limit != 0 {
+		if q != "" {
+			q += "&"
+		}
+		q += "limit=" + strconv.Itoa(p.Limit)
+	}
+	return q
+}
+
+func (c *Client) listFineTuningJobs(ctx context.Context, url string) (FineTuningJobList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return FineTuningJobList{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return FineTuningJobList{}, fmt.Errorf("failed to list fine tuning jobs %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return FineTuningJobList{}, fmt.Errorf("finetune: unexpected status %s", resp.Status)
+	}
+
+	var list FineTuningJobList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return FineTuningJobList{}, err
+	}
+
+	return list, nil
+}
+
+// WaitForJob polls RetrieveFineTuningJob every pollInterval until id reaches a
+// terminal state, and returns the resulting fine-tuned model name. A
+// non-succeeded terminal state (failed/cancelled) is returned as an error.
+func (c *Client) WaitForJob(ctx context.Context, id string, pollInterval time.Duration) (string, error) {
+	for {
+		job, err := c.RetrieveFineTuningJob(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		switch job.Status {
+		case StatusSucceeded:
+			return job.FineTunedModel, nil
+		case StatusFailed, StatusCancelled:
+			return "", fmt.Errorf("fine-tuning job %q ended with status %q", id, job.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package historystore lets a Client persist conversation history outside of
+// process memory, so a long-running service can restart and resume every
+// user's conversation. It defines the Store interface against
+// provider.Message (the same neutral message type the provider package
+// already uses) to avoid importing the root chatclient package.
+package historystore
+
+import "github.com/m-ariany/gpt-chat-client/provider"
+
+// Store persists the message history of one or more conversations, each
+// addressed by an opaque conversation ID. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Load returns convID's full history, in order. A conversation with no
+	// stored messages yet returns an empty slice and a nil error.
+	Load(convID string) ([]provider.Message, error)
+
+	// Append adds msg to the end of convID's history.
+	Append(convID string, msg provider.Message) error
+
+	// Trim evicts messages from convID's history according to policy.
+	Trim(convID string, policy TrimPolicy) error
+
+	// List returns the IDs of every conversation currently in the store.
+	List() ([]string, error)
+
+	// Delete removes convID and all of its history from the store.
+	Delete(convID string) error
+}
+
+// TrimPolicy controls how Store.Trim evicts messages from a conversation.
+type TrimPolicy struct {
+	// Keep is the number of most recent messages to retain; older messages
+	// are evicted. A Keep of 0 is a no-op.
+	Keep int
+}
@@ -0,0 +1,66 @@
+package historystore
+
+import (
+	"sync"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// MemoryStore is the default, in-process Store: conversation history lives
+// only as long as the MemoryStore itself and does not survive a restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data map[string][]provider.Message
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[string][]provider.Message{}}
+}
+
+func (s *MemoryStore) Load(convID string) ([]provider.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]provider.Message(nil), s.data[convID]...), nil
+}
+
+func (s *MemoryStore) Append(convID string, msg provider.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[convID] = append(s.data[convID], msg)
+	return nil
+}
+
+func (s *MemoryStore) Trim(convID string, policy TrimPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.data[convID]
+	if policy.Keep <= 0 || len(history) <= policy.Keep {
+		return nil
+	}
+
+	s.data[convID] = append([]provider.Message(nil), history[len(history)-policy.Keep:]...)
+	return nil
+}
+
+func (s *MemoryStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.data))
+	for id := range s.data {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *MemoryStore) Delete(convID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, convID)
+	return nil
+}
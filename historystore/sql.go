@@ -0,0 +1,226 @@
+package historystore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// Dialect selects the SQL syntax SQLStore generates. chatclient does not
+// import any database driver itself; callers open db with whichever driver
+// matches their Dialect (e.g. mattn/go-sqlite3, lib/pq, go-sql-driver/mysql)
+// and pass the resulting *sql.DB to NewSQLStore.
+type Dialect int
+
+const (
+	// DialectSQLite targets SQLite.
+	DialectSQLite Dialect = iota
+	// DialectPostgres targets PostgreSQL.
+	DialectPostgres
+	// DialectMySQL targets MySQL.
+	DialectMySQL
+)
+
+const historyTable = "chatclient_history"
+
+// SQLStore is a Store backed by a SQL database, reachable through any driver
+// the caller has registered with database/sql. It runs its own schema
+// migration on NewSQLStore, so callers only need to point it at an empty or
+// already-migrated database.
+//
+// SQLStore serializes all of its operations behind an in-process mutex; it
+// does not rely on database-level row locking, so it is safe for concurrent
+// use within one process but, like most of this package, is not meant to
+// arbitrate between multiple processes writing the same conversation ID.
+type SQLStore struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore wraps db (already open against a SQLite, PostgreSQL or MySQL
+// database matching dialect) and creates its schema if it does not exist yet.
+func NewSQLStore(db *sql.DB, dialect Dialect) (*SQLStore, error) {
+	s := &SQLStore{db: db, dialect: dialect}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("historystore: migration failed: %w", err)
+	}
+	return s, nil
+}
+
+func (s *SQLStore) migrate() error {
+	var ddl string
+	switch s.dialect {
+	case DialectPostgres:
+		ddl = `CREATE TABLE IF NOT EXISTS ` + historyTable + ` (
+			conv_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			tool_calls TEXT,
+			tool_call_id TEXT,
+			PRIMARY KEY (conv_id, seq)
+		)`
+	case DialectMySQL:
+		ddl = `CREATE TABLE IF NOT EXISTS ` + historyTable + ` (
+			conv_id VARCHAR(255) NOT NULL,
+			seq INTEGER NOT NULL,
+			role VARCHAR(32) NOT NULL,
+			content MEDIUMTEXT NOT NULL,
+			tool_calls MEDIUMTEXT,
+			tool_call_id VARCHAR(255),
+			PRIMARY KEY (conv_id, seq)
+		)`
+	default: // DialectSQLite
+		ddl = `CREATE TABLE IF NOT EXISTS ` + historyTable + ` (
+			conv_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			tool_calls TEXT,
+			tool_call_id TEXT,
+			PRIMARY KEY (conv_id, seq)
+		)`
+	}
+
+	_, err := s.db.Exec(ddl)
+	return err
+}
+
+// placeholder returns the i'th (1-based) bind placeholder for s's dialect.
+func (s *SQLStore) placeholder(i int) string {
+	if s.dialect == DialectPostgres {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+func (s *SQLStore) Load(convID string) ([]provider.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := fmt.Sprintf(
+		`SELECT role, content, tool_calls, tool_call_id FROM %s WHERE conv_id = %s ORDER BY seq ASC`,
+		historyTable, s.placeholder(1))
+
+	rows, err := s.db.Query(query, convID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []provider.Message
+	for rows.Next() {
+		var m provider.Message
+		var toolCalls, toolCallID sql.NullString
+		if err := rows.Scan(&m.Role, &m.Content, &toolCalls, &toolCallID); err != nil {
+			return nil, err
+		}
+		if toolCalls.Valid && toolCalls.String != "" {
+			if err := json.Unmarshal([]byte(toolCalls.String), &m.ToolCalls); err != nil {
+				return nil, fmt.Errorf("historystore: decoding tool_calls for %q: %w", convID, err)
+			}
+		}
+		m.ToolCallID = toolCallID.String
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+func (s *SQLStore) Append(convID string, msg provider.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var toolCalls []byte
+	if len(msg.ToolCalls) > 0 {
+		var err error
+		if toolCalls, err = json.Marshal(msg.ToolCalls); err != nil {
+			return fmt.Errorf("historystore: encoding tool_calls for %q: %w", convID, err)
+		}
+	}
+
+	nextSeqQuery := fmt.Sprintf(
+		`SELECT COALESCE(MAX(seq), 0) + 1 FROM %s WHERE conv_id = %s`,
+		historyTable, s.placeholder(1))
+
+	var seq int
+	if err := s.db.QueryRow(nextSeqQuery, convID).Scan(&seq); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(
+		`INSERT INTO %s (conv_id, seq, role, content, tool_calls, tool_call_id) VALUES (%s, %s, %s, %s, %s, %s)`,
+		historyTable, s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4), s.placeholder(5), s.placeholder(6))
+
+	_, err := s.db.Exec(insert, convID, seq, msg.Role, msg.Content, string(toolCalls), msg.ToolCallID)
+	return err
+}
+
+func (s *SQLStore) Trim(convID string, policy TrimPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if policy.Keep <= 0 {
+		return nil
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE conv_id = %s`, historyTable, s.placeholder(1))
+	var count int
+	if err := s.db.QueryRow(countQuery, convID).Scan(&count); err != nil {
+		return err
+	}
+	if count <= policy.Keep {
+		return nil
+	}
+
+	thresholdQuery := fmt.Sprintf(
+		`SELECT seq FROM %s WHERE conv_id = %s ORDER BY seq ASC LIMIT 1 OFFSET %d`,
+		historyTable, s.placeholder(1), count-policy.Keep)
+
+	var threshold int
+	if err := s.db.QueryRow(thresholdQuery, convID).Scan(&threshold); err != nil {
+		return err
+	}
+
+	del := fmt.Sprintf(
+		`DELETE FROM %s WHERE conv_id = %s AND seq < %s`,
+		historyTable, s.placeholder(1), s.placeholder(2))
+
+	_, err := s.db.Exec(del, convID, threshold)
+	return err
+}
+
+func (s *SQLStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT DISTINCT conv_id FROM %s`, historyTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (s *SQLStore) Delete(convID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	del := fmt.Sprintf(`DELETE FROM %s WHERE conv_id = %s`, historyTable, s.placeholder(1))
+	_, err := s.db.Exec(del, convID)
+	return err
+}
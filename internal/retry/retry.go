@@ -0,0 +1,79 @@
+// Package retry implements the exponential-backoff retry handler shared by
+// chatclient and chatclient/finetune, so the two packages issue HTTP calls to
+// the same provider family with identical retry/backoff behavior.
+package retry
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Handler retries a CallFunc up to maxRetry additional times, backing off
+// with jittered exponential delay between attempts, capped at maxDelay.
+type Handler struct {
+	rndMu    sync.Mutex
+	maxDelay time.Duration
+	maxRetry int
+}
+
+// New builds a Handler that retries up to maxRetry times, waiting at most
+// maxDelay between attempts.
+func New(maxDelay time.Duration, maxRetry int) *Handler {
+	return &Handler{
+		rndMu:    sync.Mutex{},
+		maxDelay: maxDelay,
+		maxRetry: maxRetry,
+	}
+}
+
+// CallFunc is the operation Do retries. A nil error stops retrying.
+type CallFunc func() error
+
+// Do calls c, retrying up to h.maxRetry additional times (backing off between
+// attempts) until c returns a nil error or the retries are exhausted.
+func (h *Handler) Do(c CallFunc) {
+	for i := 0; i < h.maxRetry+1; i++ {
+		if err := c(); err == nil {
+			return
+		}
+		if i < h.maxRetry {
+			h.backoff(i)
+		}
+	}
+}
+
+// backoff is blocking and returns after the backoff duration.
+func (h *Handler) backoff(retryCount int) {
+
+	if h.maxDelay == 0 {
+		return
+	}
+
+	h.rndMu.Lock()
+	defer h.rndMu.Unlock()
+
+	t := time.Duration(1<<uint(retryCount)) * time.Second
+	backoff := time.Duration(math.Min(float64(t), float64(h.maxDelay)))
+	center := backoff / 2
+	var ri = int64(center)
+	var jitter = newRnd(ri)
+
+	sleepTime := time.Duration(math.Abs(float64(ri + jitter)))
+	if sleepTime > h.maxDelay {
+		sleepTime = h.maxDelay
+	}
+	<-time.After(sleepTime)
+}
+
+func newRnd(cap int64) int64 {
+	// Generate a random number between 0 and cap
+	randomInt, err := rand.Int(rand.Reader, big.NewInt(cap-1))
+	if err != nil {
+		return 0
+	}
+
+	return randomInt.Int64()
+}
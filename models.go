@@ -2,6 +2,7 @@ package chatclient
 
 import (
 	"strings"
+	"sync"
 )
 
 // Ref: https://platform.openai.com/docs/models
@@ -11,6 +12,7 @@ type ModelCategory int
 const (
 	gpt35Model ModelCategory = iota
 	gpt4Model
+	reasoningModel
 	unknown
 
 	instructionTokenBuffer = 100
@@ -182,7 +184,109 @@ func (g gpt35turbo) Name() string {
 
 // gpt35 ---!>
 
+// <!+++ reasoning (o1/o3)
+
+// reasoningBase models (o1/o3 family) have a 128k context window and, per the
+// current beta, reject system-role messages, so MaxInstructionLength is not
+// meaningful for them the way it is for the chat models above.
+type reasoningBase struct{}
+
+func (g reasoningBase) ContextLength() int {
+	return 128 * 1000
+}
+
+func (g reasoningBase) MaxInstructionLength() int {
+	return g.ContextLength() - instructionTokenBuffer
+}
+
+type o1Preview struct {
+	reasoningBase
+}
+
+func (g o1Preview) Name() string {
+	return "o1-preview"
+}
+
+func (g o1Preview) CompletionLength() int {
+	return 32768
+}
+
+type o1Mini struct {
+	reasoningBase
+}
+
+func (g o1Mini) Name() string {
+	return "o1-mini"
+}
+
+func (g o1Mini) CompletionLength() int {
+	return 65536
+}
+
+type o3Mini struct {
+	reasoningBase
+}
+
+func (g o3Mini) Name() string {
+	return "o3-mini"
+}
+
+func (g o3Mini) CompletionLength() int {
+	return 65536
+}
+
+// reasoning ---!>
+
+// customModel wraps a fine-tuned model id registered via RegisterCustomModel,
+// inheriting its base model's context/completion lengths.
+type customModel struct {
+	name     string
+	base     llmModel
+	category ModelCategory
+}
+
+func (m customModel) Name() string              { return m.name }
+func (m customModel) MaxInstructionLength() int { return m.base.MaxInstructionLength() }
+func (m customModel) ContextLength() int        { return m.base.ContextLength() }
+func (m customModel) CompletionLength() int     { return m.base.CompletionLength() }
+
+var (
+	customModelsMu sync.RWMutex
+	customModels   = map[string]customModel{}
+)
+
+// RegisterCustomModel registers name (e.g. a fine-tuned model id such as
+// "ft:gpt-3.5-turbo-0125:org::abc") as a derivative of base, so getModel and
+// getModelCategory recognize it and apply base's context/completion lengths
+// when trimming history and validating instructions.
+func RegisterCustomModel(name string, base llmModel) {
+	// resolved before locking: getModelCategory takes customModelsMu itself
+	// when base is, in turn, a previously registered custom model.
+	category := getModelCategory(base.Name())
+
+	customModelsMu.Lock()
+	defer customModelsMu.Unlock()
+
+	customModels[strings.TrimSpace(strings.ToLower(name))] = customModel{
+		name:     name,
+		base:     base,
+		category: category,
+	}
+}
+
+func getCustomModel(m string) (customModel, bool) {
+	customModelsMu.RLock()
+	defer customModelsMu.RUnlock()
+
+	cm, ok := customModels[strings.TrimSpace(strings.ToLower(m))]
+	return cm, ok
+}
+
 func getModel(m string) llmModel {
+	if cm, ok := getCustomModel(m); ok {
+		return cm
+	}
+
 	switch strings.TrimSpace(strings.ToLower(m)) {
 	case gpt4Turbo{}.Name():
 		return gpt4Turbo{}
@@ -211,11 +315,22 @@ func getModel(m string) llmModel {
 		return gpt35turbo{}
 	case gpt35turbo1106{}.Name():
 		return gpt35turbo1106{}
+
+	case o1Preview{}.Name():
+		return o1Preview{}
+	case o1Mini{}.Name():
+		return o1Mini{}
+	case o3Mini{}.Name():
+		return o3Mini{}
 	}
 	return nil
 }
 
 func getModelCategory(m string) ModelCategory {
+	if cm, ok := getCustomModel(m); ok {
+		return cm.category
+	}
+
 	switch strings.TrimSpace(strings.ToLower(m)) {
 
 	case gpt4Turbo{}.Name():
@@ -245,6 +360,18 @@ func getModelCategory(m string) ModelCategory {
 		return gpt35Model
 	case gpt35turbo1106{}.Name():
 		return gpt35Model
+
+	case o1Preview{}.Name():
+		return reasoningModel
+	case o1Mini{}.Name():
+		return reasoningModel
+	case o3Mini{}.Name():
+		return reasoningModel
 	}
 	return unknown
 }
+
+// isReasoningModel reports whether m belongs to the o1/o3 reasoning family.
+func isReasoningModel(m string) bool {
+	return getModelCategory(m) == reasoningModel
+}
@@ -0,0 +1,142 @@
+package chatclient
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	ai "github.com/sashabaranov/go-openai"
+)
+
+// ModerationMode selects which side(s) of a prompt/response exchange a
+// configured Moderator checks.
+type ModerationMode int
+
+const (
+	// ModerationInputOnly checks only the user's prompt.
+	ModerationInputOnly ModerationMode = iota
+	// ModerationOutputOnly checks only the assistant's response.
+	ModerationOutputOnly
+	// ModerationBoth checks both the prompt and the response.
+	ModerationBoth
+)
+
+// ModerationCategoryAction decides what happens when a Moderator flags a
+// specific category.
+type ModerationCategoryAction int
+
+const (
+	// ModerationCategoryDeny aborts the call (the default for any flagged
+	// category not listed in a ModerationCategoryAction policy).
+	ModerationCategoryDeny ModerationCategoryAction = iota
+	// ModerationCategoryAllow lets content flagged only for this category
+	// through instead of aborting.
+	ModerationCategoryAllow
+)
+
+// ModerationResult is a Moderator's verdict on one piece of text.
+type ModerationResult struct {
+	Flagged    bool
+	Categories []string
+}
+
+// Moderator screens text for policy violations. See WithModerator to wire
+// one into a Client in place of the provider's own Moderate method.
+type Moderator interface {
+	Moderate(ctx context.Context, input string) (ModerationResult, error)
+}
+
+// WithModerator makes m, gated by mode, the moderation backend for every
+// Conversation/Agent created from c, superseding the provider's own Moderate
+// method and ClientConfig.ModeratePromptMessage/ModerateResponse. It returns
+// c for chaining.
+func (c *Client) WithModerator(m Moderator, mode ModerationMode) *Client {
+	c.moderator = m
+	c.moderationMode = mode
+	return c
+}
+
+// WithCategoryPolicy overrides the default deny-on-flag behavior for
+// specific categories Moderator.Moderate reports (e.g. to let
+// violence-themed fiction through while still denying hate speech). It
+// returns c for chaining, and has no effect unless a Moderator is also
+// configured via WithModerator.
+func (c *Client) WithCategoryPolicy(policy map[string]ModerationCategoryAction) *Client {
+	c.categoryPolicy = policy
+	return c
+}
+
+// moderate runs input through c's configured Moderator and returns
+// ErrModeration if the net verdict, after categoryPolicy overrides, is to
+// deny. A flagged result with no reported categories is always denied.
+func (c *Client) moderate(ctx context.Context, input string) error {
+	result, err := c.moderator.Moderate(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	if !result.Flagged {
+		return nil
+	}
+
+	if len(result.Categories) == 0 {
+		return ErrModeration
+	}
+
+	for _, category := range result.Categories {
+		if c.categoryPolicy[category] != ModerationCategoryAllow {
+			return ErrModeration
+		}
+	}
+
+	return nil
+}
+
+// NewOpenAIModerator builds the default Moderator, calling OpenAI's
+// /v1/moderations endpoint directly via go-openai.
+func NewOpenAIModerator(apiKey string) (Moderator, error) {
+	if len(apiKey) == 0 {
+		return nil, fmt.Errorf("apiKey must be present")
+	}
+
+	return &openAIModerator{client: ai.NewClient(apiKey)}, nil
+}
+
+type openAIModerator struct {
+	client *ai.Client
+}
+
+func (m *openAIModerator) Moderate(ctx context.Context, input string) (ModerationResult, error) {
+	resp, err := m.client.Moderations(ctx, ai.ModerationRequest{
+		Input: input,
+		Model: ai.ModerationTextStable,
+	})
+	if err != nil {
+		return ModerationResult{}, err
+	}
+
+	result := resp.Results[0]
+	return ModerationResult{
+		Flagged:    result.Flagged,
+		Categories: flaggedCategories(result.Categories),
+	}, nil
+}
+
+// flaggedCategories lists the json names of rc's true fields, e.g. "hate" or
+// "self-harm/intent".
+func flaggedCategories(rc ai.ResultCategories) []string {
+	v := reflect.ValueOf(rc)
+	t := v.Type()
+
+	var categories []string
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).Bool() {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		categories = append(categories, name)
+	}
+
+	return categories
+}
@@ -0,0 +1,158 @@
+// Package provider defines the backend-agnostic surface that chatclient drives
+// to talk to a concrete LLM API (OpenAI, Anthropic, Azure OpenAI, ...).
+// Concrete backends live under chatclient/providers/<name> and implement
+// Provider without importing the root chatclient package, so chatclient can
+// import them freely to build the provider selected by ClientConfig.Provider.
+package provider
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrModerationUnsupported is returned by Moderate when the backing provider
+// does not offer a moderation endpoint.
+var ErrModerationUnsupported = errors.New("provider does not support moderation")
+
+// Message is the provider-agnostic representation of a single chat message.
+type Message struct {
+	Role    string
+	Content string
+
+	// ToolCalls is set on assistant messages that invoke one or more tools.
+	ToolCalls []ToolCall
+
+	// ToolCallID is set on role:"tool" messages to the ID of the ToolCall it answers.
+	ToolCallID string
+}
+
+// ToolCall is a single invocation of a tool requested by the model, with its
+// arguments still encoded as the raw JSON the model produced.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Tool describes a function the model may call, in JSON-schema form.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  any
+}
+
+// ChatRequest is a provider-agnostic chat completion request. Fields that a
+// given backend does not support are expected to be ignored by that backend.
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	Temperature float32
+	MaxTokens   int
+	TopP        float32
+	Stop        []string
+
+	// PresencePenalty, FrequencyPenalty, LogitBias, N, Seed and User mirror
+	// the corresponding OpenAI-shaped ChatConfig fields. A backend that
+	// doesn't support one is expected to ignore it.
+	PresencePenalty  float32
+	FrequencyPenalty float32
+	LogitBias        map[string]int
+	N                int
+	Seed             *int
+	User             string
+
+	// Stream reports whether the caller is about to issue this request
+	// through CreateChatCompletionStream rather than CreateChatCompletion. It
+	// exists so applyReasoningConstraints can validate/strip streaming for
+	// models that reject it; setting it does not itself select which
+	// Provider method is called.
+	Stream bool
+
+	// Tools lists the functions the model may call. ToolChoice is "auto",
+	// "none", "required", a specific tool name, or "" to let the backend pick
+	// its default.
+	Tools      []Tool
+	ToolChoice string
+
+	// ReasoningModel indicates the target model belongs to a reasoning family
+	// (e.g. OpenAI's o1/o3) that some backends give different treatment, such
+	// as emitting max_completion_tokens instead of max_tokens.
+	ReasoningModel  bool
+	ReasoningEffort string
+
+	// ResponseSchema requests a structured, JSON-schema-constrained response.
+	// Backends without a native structured-output mode are expected to ignore it.
+	ResponseSchema *ResponseSchema
+}
+
+// ResponseSchema requests that the model's response conform to Schema, a
+// JSON-schema document (typically produced by reflecting a Go type).
+type ResponseSchema struct {
+	Name   string
+	Schema any
+	Strict bool
+}
+
+// Usage reports token accounting for a completion, when the backend returns it.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// ChatResponse is a provider-agnostic chat completion response.
+type ChatResponse struct {
+	Content      string
+	ToolCalls    []ToolCall
+	FinishReason string
+	Usage        Usage
+}
+
+// StreamChunk carries one piece of a streamed completion, or a terminal error.
+type StreamChunk struct {
+	Content string
+	Err     error
+
+	// ToolCallDelta is set by backends that stream a tool call's arguments
+	// incrementally as the model decides to invoke a tool. Not every backend
+	// does; one that doesn't simply never sets this field.
+	ToolCallDelta *ToolCallDelta
+
+	// FinishReason is set on the final chunk by backends that report why
+	// generation stopped (e.g. "stop", "tool_calls", "length").
+	FinishReason string
+
+	// Usage is set on the final chunk by backends that report token usage
+	// for a streamed completion (e.g. Ollama's trailing done:true message),
+	// so the caller can bill exact counts instead of falling back to its
+	// own tokenizer estimate.
+	Usage *Usage
+}
+
+// ToolCallDelta is one incremental piece of a tool call's arguments as they
+// stream in. ID and Name are populated once known (typically on the first
+// delta for a given call); ArgumentsDelta is meant to be concatenated across
+// every delta sharing the same ID to reconstruct the full JSON arguments.
+type ToolCallDelta struct {
+	ID             string
+	Name           string
+	ArgumentsDelta string
+}
+
+// Tokenizer counts tokens the way the backing provider's models count them.
+type Tokenizer interface {
+	CountTokens(s string) int
+}
+
+// Provider is the interface every concrete LLM backend implements.
+type Provider interface {
+	CreateChatCompletion(ctx context.Context, req ChatRequest) (ChatResponse, error)
+	CreateChatCompletionStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error)
+
+	// Moderate reports whether input is flagged by the provider's moderation
+	// endpoint. Providers that do not offer one return ErrModerationUnsupported.
+	Moderate(ctx context.Context, input string) (bool, error)
+
+	// Tokenizer returns the token counter to use for this provider's models.
+	Tokenizer() Tokenizer
+}
@@ -0,0 +1,397 @@
+// Package anthropic implements provider.Provider on top of Anthropic's
+// Messages API (https://docs.anthropic.com/en/api/messages).
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+const (
+	defaultBaseURL    = "https://api.anthropic.com"
+	defaultAPIVersion = "2023-06-01"
+	systemRole        = "system"
+)
+
+// Config holds the settings needed to talk to the Anthropic Messages API.
+type Config struct {
+	// ApiKey is the Anthropic API key, sent as the x-api-key header.
+	ApiKey string
+
+	// BaseURL overrides the default Anthropic API base URL.
+	BaseURL string
+
+	// ApiVersion overrides the anthropic-version header, defaults to "2023-06-01".
+	ApiVersion string
+
+	// HTTPClient overrides the default *http.Client used for requests.
+	HTTPClient *http.Client
+}
+
+type Provider struct {
+	apiKey     string
+	baseURL    string
+	apiVersion string
+	httpClient *http.Client
+	tokenizer  tokenizer
+}
+
+// New builds an anthropic Provider from cnf.
+func New(cnf Config) (*Provider, error) {
+	if len(cnf.ApiKey) == 0 {
+		return nil, fmt.Errorf("ApiKey must be present")
+	}
+
+	baseURL := defaultBaseURL
+	if len(cnf.BaseURL) > 0 {
+		baseURL = cnf.BaseURL
+	}
+
+	apiVersion := defaultAPIVersion
+	if len(cnf.ApiVersion) > 0 {
+		apiVersion = cnf.ApiVersion
+	}
+
+	httpClient := cnf.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+
+	return &Provider{
+		apiKey:     cnf.ApiKey,
+		baseURL:    baseURL,
+		apiVersion: apiVersion,
+		httpClient: httpClient,
+	}, nil
+}
+
+// contentBlock is a single block of a message's content. Anthropic messages
+// carry an array of typed blocks rather than a flat string once tool use is
+// involved: "text" for plain content, "tool_use" for an assistant-requested
+// call, and "tool_result" for the result fed back as a user-role message.
+type contentBlock struct {
+	Type string `json:"type"`
+
+	// Text is set on "text" blocks.
+	Text string `json:"text,omitempty"`
+
+	// ID and Name are set on "tool_use" blocks; Input holds the call's
+	// arguments, decoded from the model's raw JSON since Anthropic wants them
+	// as a JSON object rather than a string.
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Input any    `json:"input,omitempty"`
+
+	// ToolUseID and Content are set on "tool_result" blocks, referencing the
+	// "tool_use" block they answer.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+type tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+	StopSeqs    []string  `json:"stop_sequences,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+	Tools       []tool    `json:"tools,omitempty"`
+	ToolChoice  any       `json:"tool_choice,omitempty"`
+}
+
+type messagesResponse struct {
+	Content []struct {
+		Type  string `json:"type"`
+		Text  string `json:"text"`
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Input any    `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// toAnthropicMessage converts m to Anthropic's content-block form. An
+// assistant message with ToolCalls becomes a "tool_use" block per call
+// (alongside any text); a role:"tool" result becomes a "tool_result" block
+// under role "user", since Anthropic has no separate tool role.
+func toAnthropicMessage(m provider.Message) message {
+	role := m.Role
+
+	if m.Role == "tool" {
+		// Arguments/results travel as opaque strings in provider.Message, so
+		// the result is carried as a single string content.
+		return message{
+			Role: "user",
+			Content: []contentBlock{{
+				Type:      "tool_result",
+				ToolUseID: m.ToolCallID,
+				Content:   m.Content,
+			}},
+		}
+	}
+
+	var blocks []contentBlock
+	if m.Content != "" {
+		blocks = append(blocks, contentBlock{Type: "text", Text: m.Content})
+	}
+	for _, call := range m.ToolCalls {
+		var input any
+		// call.Arguments is the model's raw JSON object; decode errors fall
+		// back to an empty object rather than failing the whole request.
+		if err := json.Unmarshal([]byte(call.Arguments), &input); err != nil {
+			input = map[string]any{}
+		}
+		blocks = append(blocks, contentBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Name,
+			Input: input,
+		})
+	}
+
+	return message{Role: role, Content: blocks}
+}
+
+func toAnthropicTools(tools []provider.Tool) []tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := make([]tool, len(tools))
+	for i, t := range tools {
+		out[i] = tool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters}
+	}
+
+	return out
+}
+
+// toAnthropicToolChoice translates provider.ChatRequest.ToolChoice's OpenAI-
+// shaped values ("auto", "none", "required", a tool name) into Anthropic's
+// tool_choice object form.
+func toAnthropicToolChoice(choice string) any {
+	switch choice {
+	case "":
+		return nil
+	case "auto":
+		return map[string]string{"type": "auto"}
+	case "required":
+		return map[string]string{"type": "any"}
+	case "none":
+		return map[string]string{"type": "none"}
+	default:
+		return map[string]string{"type": "tool", "name": choice}
+	}
+}
+
+func toAnthropicRequest(req provider.ChatRequest, stream bool) messagesRequest {
+	// Anthropic takes the system prompt as a top-level field rather than a
+	// message with role "system".
+	var system strings.Builder
+	messages := make([]message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == systemRole {
+			if system.Len() > 0 {
+				system.WriteString("\n")
+			}
+			system.WriteString(m.Content)
+			continue
+		}
+		messages = append(messages, toAnthropicMessage(m))
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+
+	return messagesRequest{
+		Model:       req.Model,
+		System:      system.String(),
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		StopSeqs:    req.Stop,
+		Stream:      stream,
+		Tools:       toAnthropicTools(req.Tools),
+		ToolChoice:  toAnthropicToolChoice(req.ToolChoice),
+	}
+}
+
+func (p *Provider) newRequest(ctx context.Context, body any) (*http.Request, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("content-type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", p.apiVersion)
+
+	return httpReq, nil
+}
+
+func (p *Provider) CreateChatCompletion(ctx context.Context, req provider.ChatRequest) (provider.ChatResponse, error) {
+	httpReq, err := p.newRequest(ctx, toAnthropicRequest(req, false))
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return provider.ChatResponse{}, fmt.Errorf("failed to create chat completion %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return provider.ChatResponse{}, fmt.Errorf("anthropic: unexpected status %s", resp.Status)
+	}
+
+	var data messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	var content strings.Builder
+	var toolCalls []provider.ToolCall
+	for _, block := range data.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			// Input arrived as a decoded JSON value; re-encode it to the raw
+			// JSON string provider.ToolCall.Arguments expects. Encoding a
+			// value json.Unmarshal itself produced never fails.
+			args, _ := json.Marshal(block.Input)
+			toolCalls = append(toolCalls, provider.ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(args),
+			})
+		}
+	}
+
+	return provider.ChatResponse{
+		Content:      content.String(),
+		ToolCalls:    toolCalls,
+		FinishReason: data.StopReason,
+		Usage: provider.Usage{
+			PromptTokens:     data.Usage.InputTokens,
+			CompletionTokens: data.Usage.OutputTokens,
+			TotalTokens:      data.Usage.InputTokens + data.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// sseEvent mirrors the subset of Anthropic's streaming event payloads needed
+// to reassemble the text delta. Ref: https://docs.anthropic.com/en/api/messages-streaming
+type sseEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamChunk, error) {
+	httpReq, err := p.newRequest(ctx, toAnthropicRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("anthropic: unexpected status %s", resp.Status)
+	}
+
+	ch := make(chan provider.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+
+			var event sseEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+
+			if event.Type != "content_block_delta" || event.Delta.Type != "text_delta" {
+				continue
+			}
+
+			select {
+			case ch <- provider.StreamChunk{Content: event.Delta.Text}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			ch <- provider.StreamChunk{Err: err}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Moderate is not offered by the Anthropic API.
+func (p *Provider) Moderate(ctx context.Context, input string) (bool, error) {
+	return false, provider.ErrModerationUnsupported
+}
+
+func (p *Provider) Tokenizer() provider.Tokenizer {
+	return p.tokenizer
+}
+
+// tokenizer estimates Claude token counts with a byte-length heuristic, since
+// Anthropic does not ship an offline tokenizer equivalent to tiktoken.
+// Anthropic models average roughly 3.5 latin characters per token.
+type tokenizer struct{}
+
+func (t tokenizer) CountTokens(s string) int {
+	const avgCharsPerToken = 3.5
+	return int(float64(len(s))/avgCharsPerToken) + 1
+}
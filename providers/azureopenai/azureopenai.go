@@ -0,0 +1,231 @@
+// Package azureopenai implements provider.Provider on top of Azure OpenAI's
+// deployment-based API, reusing go-openai's built-in Azure support for the
+// request/response wire format.
+package azureopenai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkoukk/tiktoken-go"
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+const defaultAPIVersion = "2023-05-15"
+
+// Config holds the settings needed to talk to an Azure OpenAI resource.
+type Config struct {
+	// ApiKey is the Azure OpenAI resource key, sent as the api-key header.
+	ApiKey string
+
+	// Endpoint is the resource endpoint, e.g. https://my-resource.openai.azure.com.
+	Endpoint string
+
+	// Deployment is the name of the deployment backing the model given on
+	// ClientConfig.ChatConfig.Model.
+	Deployment string
+
+	// ApiVersion overrides the api-version query parameter, defaults to "2023-05-15".
+	ApiVersion string
+}
+
+type Provider struct {
+	client    *ai.Client
+	tokenizer tokenizer
+}
+
+// New builds an azureopenai Provider from cnf.
+func New(cnf Config) (*Provider, error) {
+	if len(cnf.ApiKey) == 0 {
+		return nil, fmt.Errorf("ApiKey must be present")
+	}
+	if len(cnf.Endpoint) == 0 {
+		return nil, fmt.Errorf("Endpoint must be present")
+	}
+	if len(cnf.Deployment) == 0 {
+		return nil, fmt.Errorf("Deployment must be present")
+	}
+
+	clientConfig := ai.DefaultAzureConfig(cnf.ApiKey, cnf.Endpoint)
+	if len(cnf.ApiVersion) > 0 {
+		clientConfig.APIVersion = cnf.ApiVersion
+	} else {
+		clientConfig.APIVersion = defaultAPIVersion
+	}
+	clientConfig.AzureModelMapperFunc = func(model string) string {
+		return cnf.Deployment
+	}
+
+	tkm, err := newTokenizer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		client:    ai.NewClientWithConfig(clientConfig),
+		tokenizer: tkm,
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletion(ctx context.Context, req provider.ChatRequest) (provider.ChatResponse, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, toOpenAIRequest(req, false))
+	if err != nil {
+		return provider.ChatResponse{}, fmt.Errorf("failed to create chat completion %w", err)
+	}
+
+	return provider.ChatResponse{
+		Content:      resp.Choices[0].Message.Content,
+		ToolCalls:    fromOpenAIToolCalls(resp.Choices[0].Message.ToolCalls),
+		FinishReason: string(resp.Choices[0].FinishReason),
+		Usage: provider.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamChunk, error) {
+	stream, err := p.client.CreateChatCompletionStream(ctx, toOpenAIRequest(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream %w", err)
+	}
+
+	ch := make(chan provider.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer stream.Close()
+
+		for {
+			data, err := stream.Recv()
+			if err != nil {
+				ch <- provider.StreamChunk{Err: err}
+				return
+			}
+
+			select {
+			case ch <- provider.StreamChunk{Content: data.Choices[0].Delta.Content}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Moderate is not offered on Azure OpenAI deployments.
+func (p *Provider) Moderate(ctx context.Context, input string) (bool, error) {
+	return false, provider.ErrModerationUnsupported
+}
+
+func (p *Provider) Tokenizer() provider.Tokenizer {
+	return p.tokenizer
+}
+
+func toOpenAIRequest(req provider.ChatRequest, stream bool) ai.ChatCompletionRequest {
+	messages := make([]ai.ChatCompletionMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = ai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  toOpenAIToolCalls(m.ToolCalls),
+			ToolCallID: m.ToolCallID,
+		}
+	}
+
+	request := ai.ChatCompletionRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+		Stream:      stream,
+		Tools:       toOpenAITools(req.Tools),
+	}
+
+	if req.ToolChoice != "" {
+		request.ToolChoice = req.ToolChoice
+	}
+
+	return request
+}
+
+func toOpenAITools(tools []provider.Tool) []ai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := make([]ai.Tool, len(tools))
+	for i, t := range tools {
+		out[i] = ai.Tool{
+			Type: ai.ToolTypeFunction,
+			Function: &ai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+
+	return out
+}
+
+func toOpenAIToolCalls(calls []provider.ToolCall) []ai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]ai.ToolCall, len(calls))
+	for i, c := range calls {
+		out[i] = ai.ToolCall{
+			ID:   c.ID,
+			Type: ai.ToolTypeFunction,
+			Function: ai.FunctionCall{
+				Name:      c.Name,
+				Arguments: c.Arguments,
+			},
+		}
+	}
+
+	return out
+}
+
+func fromOpenAIToolCalls(calls []ai.ToolCall) []provider.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]provider.ToolCall, len(calls))
+	for i, c := range calls {
+		out[i] = provider.ToolCall{
+			ID:        c.ID,
+			Name:      c.Function.Name,
+			Arguments: c.Function.Arguments,
+		}
+	}
+
+	return out
+}
+
+// Azure OpenAI models are counted with tiktoken's cl100k_base encoding, same
+// as their OpenAI counterparts.
+type tokenizer struct {
+	*tiktoken.Tiktoken
+}
+
+func newTokenizer() (tokenizer, error) {
+	tkm, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return tokenizer{}, err
+	}
+
+	return tokenizer{Tiktoken: tkm}, nil
+}
+
+func (t tokenizer) CountTokens(s string) int {
+	return len(t.Tiktoken.Encode(s, nil, nil))
+}
@@ -0,0 +1,403 @@
+// Package gemini implements provider.Provider on top of Google's Gemini
+// generateContent API (https://ai.google.dev/api/generate-content).
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+const (
+	defaultBaseURL = "https://generativelanguage.googleapis.com"
+	systemRole     = "system"
+	modelRole      = "model"
+	functionRole   = "function"
+)
+
+// Config holds the settings needed to talk to the Gemini API.
+type Config struct {
+	// ApiKey is the Gemini API key, sent as the ?key= query parameter.
+	ApiKey string
+
+	// BaseURL overrides the default Gemini API base URL.
+	BaseURL string
+
+	// HTTPClient overrides the default *http.Client used for requests.
+	HTTPClient *http.Client
+}
+
+type Provider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	tokenizer  tokenizer
+}
+
+// New builds a gemini Provider from cnf.
+func New(cnf Config) (*Provider, error) {
+	if len(cnf.ApiKey) == 0 {
+		return nil, fmt.Errorf("ApiKey must be present")
+	}
+
+	baseURL := defaultBaseURL
+	if len(cnf.BaseURL) > 0 {
+		baseURL = cnf.BaseURL
+	}
+
+	httpClient := cnf.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+
+	return &Provider{
+		apiKey:     cnf.ApiKey,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}, nil
+}
+
+// functionCall is a model-requested tool invocation, carried on an "model"-role
+// part. Gemini has no call ID, so the function's Name is reused to match the
+// following functionResponse part.
+type functionCall struct {
+	Name string `json:"name"`
+	Args any    `json:"args"`
+}
+
+// functionResponse carries a tool's result back to the model, on a part with
+// role functionRole.
+type functionResponse struct {
+	Name     string `json:"name"`
+	Response any    `json:"response"`
+}
+
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type generationConfig struct {
+	Temperature     float32  `json:"temperature,omitempty"`
+	TopP            float32  `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+// functionDeclaration describes one function the model may call, nested
+// under a tool entry per Gemini's schema.
+type functionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+// toolConfig selects how the model may use the declared functions, mirroring
+// provider.ChatRequest.ToolChoice's OpenAI-shaped values.
+type toolConfig struct {
+	FunctionCallingConfig struct {
+		Mode string `json:"mode"`
+	} `json:"functionCallingConfig"`
+}
+
+type generateContentRequest struct {
+	SystemInstruction *content         `json:"systemInstruction,omitempty"`
+	Contents          []content        `json:"contents"`
+	GenerationConfig  generationConfig `json:"generationConfig,omitempty"`
+	Tools             []geminiTool     `json:"tools,omitempty"`
+	ToolConfig        *toolConfig      `json:"toolConfig,omitempty"`
+}
+
+type generateContentResponse struct {
+	Candidates []struct {
+		Content      content `json:"content"`
+		FinishReason string  `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// toGeminiContent converts m into a content. A role:"tool" result becomes a
+// functionResponse part under functionRole; an assistant message with
+// ToolCalls becomes one functionCall part per call (alongside any text).
+func toGeminiContent(m provider.Message) content {
+	if m.Role == "tool" {
+		var response any
+		// m.Content is the tool's raw string result; Gemini wants the
+		// response as a JSON object, so fall back to wrapping it if it isn't one.
+		if err := json.Unmarshal([]byte(m.Content), &response); err != nil {
+			response = map[string]any{"result": m.Content}
+		}
+		return content{
+			Role:  functionRole,
+			Parts: []part{{FunctionResponse: &functionResponse{Name: m.ToolCallID, Response: response}}},
+		}
+	}
+
+	role := m.Role
+	if role == "assistant" {
+		role = modelRole
+	}
+
+	var parts []part
+	if m.Content != "" {
+		parts = append(parts, part{Text: m.Content})
+	}
+	for _, call := range m.ToolCalls {
+		var args any
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			args = map[string]any{}
+		}
+		// Gemini's functionCall has no ID, so the call's ID is carried
+		// through the name to match the following functionResponse part.
+		parts = append(parts, part{FunctionCall: &functionCall{Name: call.ID, Args: args}})
+	}
+
+	return content{Role: role, Parts: parts}
+}
+
+func toGeminiTools(tools []provider.Tool) []geminiTool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	decls := make([]functionDeclaration, len(tools))
+	for i, t := range tools {
+		decls[i] = functionDeclaration{Name: t.Name, Description: t.Description, Parameters: t.Parameters}
+	}
+
+	return []geminiTool{{FunctionDeclarations: decls}}
+}
+
+// toGeminiToolConfig translates provider.ChatRequest.ToolChoice's OpenAI-
+// shaped values ("auto", "none", a tool name) into Gemini's functionCallingConfig modes.
+func toGeminiToolConfig(choice string) *toolConfig {
+	if choice == "" {
+		return nil
+	}
+
+	cfg := &toolConfig{}
+	switch choice {
+	case "auto":
+		cfg.FunctionCallingConfig.Mode = "AUTO"
+	case "none":
+		cfg.FunctionCallingConfig.Mode = "NONE"
+	default:
+		cfg.FunctionCallingConfig.Mode = "ANY"
+	}
+
+	return cfg
+}
+
+// toGeminiRequest converts req. Gemini takes the system prompt as a
+// separate systemInstruction field, and uses role "model" rather than
+// "assistant" for prior model turns.
+func toGeminiRequest(req provider.ChatRequest) generateContentRequest {
+	var system *content
+	contents := make([]content, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == systemRole {
+			system = &content{Parts: []part{{Text: m.Content}}}
+			continue
+		}
+
+		contents = append(contents, toGeminiContent(m))
+	}
+
+	return generateContentRequest{
+		SystemInstruction: system,
+		Contents:          contents,
+		GenerationConfig: generationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			MaxOutputTokens: req.MaxTokens,
+			StopSequences:   req.Stop,
+		},
+		Tools:      toGeminiTools(req.Tools),
+		ToolConfig: toGeminiToolConfig(req.ToolChoice),
+	}
+}
+
+// fromGeminiContent extracts the plain-text portion of c and any function
+// calls it carries, reconstituting provider.ToolCall.Arguments/ID from the
+// functionCall's Args/Name (see toGeminiContent for why ID is round-tripped
+// through Name).
+func fromGeminiContent(c content) (text string, calls []provider.ToolCall) {
+	var sb strings.Builder
+	for _, p := range c.Parts {
+		sb.WriteString(p.Text)
+		if p.FunctionCall != nil {
+			// Args arrived as a decoded JSON value; re-encode it to the raw
+			// JSON string provider.ToolCall.Arguments expects. Encoding a
+			// value json.Unmarshal itself produced never fails.
+			args, _ := json.Marshal(p.FunctionCall.Args)
+			calls = append(calls, provider.ToolCall{
+				ID:        p.FunctionCall.Name,
+				Name:      p.FunctionCall.Name,
+				Arguments: string(args),
+			})
+		}
+	}
+	return sb.String(), calls
+}
+
+func (p *Provider) endpoint(model, method string) string {
+	return fmt.Sprintf("%s/v1beta/models/%s:%s?key=%s", p.baseURL, model, method, p.apiKey)
+}
+
+func (p *Provider) newRequest(ctx context.Context, url string, body any) (*http.Request, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("content-type", "application/json")
+
+	return httpReq, nil
+}
+
+func (p *Provider) CreateChatCompletion(ctx context.Context, req provider.ChatRequest) (provider.ChatResponse, error) {
+	httpReq, err := p.newRequest(ctx, p.endpoint(req.Model, "generateContent"), toGeminiRequest(req))
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return provider.ChatResponse{}, fmt.Errorf("failed to create chat completion %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return provider.ChatResponse{}, fmt.Errorf("gemini: unexpected status %s", resp.Status)
+	}
+
+	var data generateContentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	if len(data.Candidates) == 0 {
+		return provider.ChatResponse{}, fmt.Errorf("gemini: response had no candidates")
+	}
+
+	candidate := data.Candidates[0]
+	text, calls := fromGeminiContent(candidate.Content)
+	return provider.ChatResponse{
+		Content:      text,
+		ToolCalls:    calls,
+		FinishReason: candidate.FinishReason,
+		Usage: provider.Usage{
+			PromptTokens:     data.UsageMetadata.PromptTokenCount,
+			CompletionTokens: data.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      data.UsageMetadata.TotalTokenCount,
+		},
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamChunk, error) {
+	httpReq, err := p.newRequest(ctx, p.endpoint(req.Model, "streamGenerateContent")+"&alt=sse", toGeminiRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("gemini: unexpected status %s", resp.Status)
+	}
+
+	ch := make(chan provider.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var usage provider.Usage
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+
+			var event generateContentResponse
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+
+			usage = provider.Usage{
+				PromptTokens:     event.UsageMetadata.PromptTokenCount,
+				CompletionTokens: event.UsageMetadata.CandidatesTokenCount,
+				TotalTokens:      event.UsageMetadata.TotalTokenCount,
+			}
+
+			if len(event.Candidates) == 0 {
+				continue
+			}
+
+			text, _ := fromGeminiContent(event.Candidates[0].Content)
+			select {
+			case ch <- provider.StreamChunk{Content: text}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			ch <- provider.StreamChunk{Err: err}
+			return
+		}
+
+		ch <- provider.StreamChunk{Usage: &usage}
+	}()
+
+	return ch, nil
+}
+
+// Moderate is not offered by the Gemini API.
+func (p *Provider) Moderate(ctx context.Context, input string) (bool, error) {
+	return false, provider.ErrModerationUnsupported
+}
+
+func (p *Provider) Tokenizer() provider.Tokenizer {
+	return p.tokenizer
+}
+
+// tokenizer estimates Gemini token counts with a byte-length heuristic, since
+// Gemini does not ship an offline tokenizer equivalent to tiktoken.
+type tokenizer struct{}
+
+func (t tokenizer) CountTokens(s string) int {
+	const avgCharsPerToken = 4.0
+	return int(float64(len(s))/avgCharsPerToken) + 1
+}
@@ -0,0 +1,304 @@
+// Package ollama implements provider.Provider on top of a local Ollama
+// server's chat API (https://github.com/ollama/ollama/blob/main/docs/api.md#generate-a-chat-completion).
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+// Config holds the settings needed to talk to an Ollama server.
+type Config struct {
+	// BaseURL overrides the default Ollama server URL ("http://localhost:11434").
+	BaseURL string
+
+	// HTTPClient overrides the default *http.Client used for requests.
+	HTTPClient *http.Client
+}
+
+type Provider struct {
+	baseURL    string
+	httpClient *http.Client
+	tokenizer  tokenizer
+}
+
+// New builds an ollama Provider from cnf.
+func New(cnf Config) (*Provider, error) {
+	baseURL := defaultBaseURL
+	if len(cnf.BaseURL) > 0 {
+		baseURL = cnf.BaseURL
+	}
+
+	httpClient := cnf.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+
+	return &Provider{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}, nil
+}
+
+// toolCall is Ollama's shape for a model-requested tool invocation. Unlike
+// OpenAI, Ollama has no call ID; Function.Name is reused to line up the
+// following role:"tool" result message.
+type toolCall struct {
+	Function struct {
+		Name      string `json:"name"`
+		Arguments any    `json:"arguments"`
+	} `json:"function"`
+}
+
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+type function struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type tool struct {
+	Type     string   `json:"type"`
+	Function function `json:"function"`
+}
+
+type options struct {
+	Temperature float32  `json:"temperature,omitempty"`
+	TopP        float32  `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Options  options   `json:"options,omitempty"`
+	Tools    []tool    `json:"tools,omitempty"`
+}
+
+type chatResponse struct {
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+}
+
+// toOllamaMessage converts m. An assistant message's ToolCalls become
+// Ollama's tool_calls; a role:"tool" result is passed through unchanged,
+// since Ollama accepts a plain role:"tool" message like OpenAI.
+func toOllamaMessage(m provider.Message) message {
+	msg := message{Role: m.Role, Content: m.Content}
+
+	for _, call := range m.ToolCalls {
+		var args any
+		// call.Arguments is the model's raw JSON object; Ollama wants it
+		// decoded rather than as a string.
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			args = map[string]any{}
+		}
+		tc := toolCall{}
+		tc.Function.Name = call.Name
+		tc.Function.Arguments = args
+		msg.ToolCalls = append(msg.ToolCalls, tc)
+	}
+
+	return msg
+}
+
+func toOllamaTools(tools []provider.Tool) []tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := make([]tool, len(tools))
+	for i, t := range tools {
+		out[i] = tool{
+			Type:     "function",
+			Function: function{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		}
+	}
+
+	return out
+}
+
+func toOllamaRequest(req provider.ChatRequest, stream bool) chatRequest {
+	messages := make([]message, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = toOllamaMessage(m)
+	}
+
+	return chatRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Stream:   stream,
+		Options: options{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			Stop:        req.Stop,
+		},
+		Tools: toOllamaTools(req.Tools),
+	}
+}
+
+// fromOllamaToolCalls reconstitutes provider.ToolCall from Ollama's
+// tool_calls, re-encoding Arguments back to the raw JSON string
+// provider.ToolCall carries, and reusing the function name as the call's ID
+// (see toolCall's doc comment for why).
+func fromOllamaToolCalls(calls []toolCall) []provider.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]provider.ToolCall, len(calls))
+	for i, c := range calls {
+		// Arguments arrived as a decoded JSON value; re-encode it to the raw
+		// JSON string provider.ToolCall.Arguments expects. Encoding a value
+		// json.Unmarshal itself produced never fails.
+		args, _ := json.Marshal(c.Function.Arguments)
+		out[i] = provider.ToolCall{
+			ID:        c.Function.Name,
+			Name:      c.Function.Name,
+			Arguments: string(args),
+		}
+	}
+
+	return out
+}
+
+func (p *Provider) newRequest(ctx context.Context, body any) (*http.Request, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("content-type", "application/json")
+
+	return httpReq, nil
+}
+
+func usageFrom(r chatResponse) provider.Usage {
+	return provider.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+func (p *Provider) CreateChatCompletion(ctx context.Context, req provider.ChatRequest) (provider.ChatResponse, error) {
+	httpReq, err := p.newRequest(ctx, toOllamaRequest(req, false))
+	if err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return provider.ChatResponse{}, fmt.Errorf("failed to create chat completion %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return provider.ChatResponse{}, fmt.Errorf("ollama: unexpected status %s", resp.Status)
+	}
+
+	var data chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	return provider.ChatResponse{
+		Content:      data.Message.Content,
+		ToolCalls:    fromOllamaToolCalls(data.Message.ToolCalls),
+		FinishReason: data.DoneReason,
+		Usage:        usageFrom(data),
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamChunk, error) {
+	httpReq, err := p.newRequest(ctx, toOllamaRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ollama: unexpected status %s", resp.Status)
+	}
+
+	ch := make(chan provider.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		// Ollama streams one JSON object per line, not SSE.
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var event chatResponse
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+
+			if event.Message.Content != "" {
+				select {
+				case ch <- provider.StreamChunk{Content: event.Message.Content}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if event.Done {
+				usage := usageFrom(event)
+				ch <- provider.StreamChunk{Usage: &usage}
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			ch <- provider.StreamChunk{Err: err}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Moderate is not offered by Ollama.
+func (p *Provider) Moderate(ctx context.Context, input string) (bool, error) {
+	return false, provider.ErrModerationUnsupported
+}
+
+func (p *Provider) Tokenizer() provider.Tokenizer {
+	return p.tokenizer
+}
+
+// tokenizer estimates token counts with a byte-length heuristic, since token
+// counts vary by the locally-hosted model and Ollama exposes no offline
+// tokenizer.
+type tokenizer struct{}
+
+func (t tokenizer) CountTokens(s string) int {
+	const avgCharsPerToken = 4.0
+	return int(float64(len(s))/avgCharsPerToken) + 1
+}
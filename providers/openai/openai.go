@@ -0,0 +1,253 @@
+// Package openai implements provider.Provider on top of go-openai, preserving
+// the behavior chatclient relied on before the Provider abstraction existed.
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkoukk/tiktoken-go"
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// Config holds the settings needed to talk to the OpenAI (or OpenAI-compatible)
+// chat completions API.
+type Config struct {
+	// ApiKey is the authentication key required to access the OpenAI API.
+	ApiKey string
+
+	// ApiUrl overrides the default OpenAI API base URL, e.g. for
+	// OpenAI-compatible third-party endpoints.
+	ApiUrl string
+}
+
+type Provider struct {
+	client    *ai.Client
+	tokenizer tokenizer
+}
+
+// New builds an openai Provider from cnf.
+func New(cnf Config) (*Provider, error) {
+	if len(cnf.ApiKey) == 0 {
+		return nil, fmt.Errorf("ApiKey must be present")
+	}
+
+	clientConfig := ai.DefaultConfig(cnf.ApiKey)
+	if len(cnf.ApiUrl) > 0 {
+		clientConfig.BaseURL = cnf.ApiUrl
+	} else {
+		clientConfig.BaseURL = "https://api.openai.com/v1"
+	}
+
+	tkm, err := newTokenizer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		client:    ai.NewClientWithConfig(clientConfig),
+		tokenizer: tkm,
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletion(ctx context.Context, req provider.ChatRequest) (provider.ChatResponse, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, toOpenAIRequest(req, false))
+	if err != nil {
+		return provider.ChatResponse{}, fmt.Errorf("failed to create chat completion %w", err)
+	}
+
+	msg := resp.Choices[0].Message
+	return provider.ChatResponse{
+		Content:      msg.Content,
+		ToolCalls:    fromOpenAIToolCalls(msg.ToolCalls),
+		FinishReason: string(resp.Choices[0].FinishReason),
+		Usage: provider.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req provider.ChatRequest) (<-chan provider.StreamChunk, error) {
+	stream, err := p.client.CreateChatCompletionStream(ctx, toOpenAIRequest(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream %w", err)
+	}
+
+	ch := make(chan provider.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer stream.Close()
+
+		for {
+			data, err := stream.Recv()
+			if err != nil {
+				ch <- provider.StreamChunk{Err: err}
+				return
+			}
+
+			select {
+			case ch <- provider.StreamChunk{Content: data.Choices[0].Delta.Content}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (p *Provider) Moderate(ctx context.Context, input string) (bool, error) {
+	result, err := p.client.Moderations(ctx, ai.ModerationRequest{
+		Input: input,
+		Model: ai.ModerationTextStable,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.Results[0].Flagged, nil
+}
+
+func (p *Provider) Tokenizer() provider.Tokenizer {
+	return p.tokenizer
+}
+
+func toOpenAIRequest(req provider.ChatRequest, stream bool) ai.ChatCompletionRequest {
+	messages := make([]ai.ChatCompletionMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = ai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  toOpenAIToolCalls(m.ToolCalls),
+			ToolCallID: m.ToolCallID,
+		}
+	}
+
+	request := ai.ChatCompletionRequest{
+		Model:            req.Model,
+		Messages:         messages,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stop:             req.Stop,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		LogitBias:        req.LogitBias,
+		N:                req.N,
+		Seed:             req.Seed,
+		User:             req.User,
+		Stream:           stream,
+		Tools:            toOpenAITools(req.Tools),
+	}
+
+	if req.ToolChoice != "" {
+		request.ToolChoice = req.ToolChoice
+	}
+
+	if req.ReasoningModel {
+		request.MaxCompletionTokens = req.MaxTokens
+		request.ReasoningEffort = req.ReasoningEffort
+	} else {
+		request.MaxTokens = req.MaxTokens
+	}
+
+	if req.ResponseSchema != nil {
+		request.ResponseFormat = toOpenAIResponseFormat(req.ResponseSchema)
+	}
+
+	return request
+}
+
+func toOpenAIResponseFormat(s *provider.ResponseSchema) *ai.ChatCompletionResponseFormat {
+	// s.Schema is built by reflecting a Go type into plain maps/slices/
+	// primitives, which json.Marshal never fails on.
+	schema, _ := json.Marshal(s.Schema)
+
+	return &ai.ChatCompletionResponseFormat{
+		Type: ai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &ai.ChatCompletionResponseFormatJSONSchema{
+			Name:   s.Name,
+			Schema: json.RawMessage(schema),
+			Strict: s.Strict,
+		},
+	}
+}
+
+func toOpenAITools(tools []provider.Tool) []ai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := make([]ai.Tool, len(tools))
+	for i, t := range tools {
+		out[i] = ai.Tool{
+			Type: ai.ToolTypeFunction,
+			Function: &ai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+
+	return out
+}
+
+func toOpenAIToolCalls(calls []provider.ToolCall) []ai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]ai.ToolCall, len(calls))
+	for i, c := range calls {
+		out[i] = ai.ToolCall{
+			ID:   c.ID,
+			Type: ai.ToolTypeFunction,
+			Function: ai.FunctionCall{
+				Name:      c.Name,
+				Arguments: c.Arguments,
+			},
+		}
+	}
+
+	return out
+}
+
+func fromOpenAIToolCalls(calls []ai.ToolCall) []provider.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]provider.ToolCall, len(calls))
+	for i, c := range calls {
+		out[i] = provider.ToolCall{
+			ID:        c.ID,
+			Name:      c.Function.Name,
+			Arguments: c.Function.Arguments,
+		}
+	}
+
+	return out
+}
+
+// OpenAI models are counted with tiktoken's cl100k_base encoding.
+type tokenizer struct {
+	*tiktoken.Tiktoken
+}
+
+func newTokenizer() (tokenizer, error) {
+	tkm, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return tokenizer{}, err
+	}
+
+	return tokenizer{Tiktoken: tkm}, nil
+}
+
+func (t tokenizer) CountTokens(s string) int {
+	return len(t.Tiktoken.Encode(s, nil, nil))
+}
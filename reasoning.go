@@ -0,0 +1,56 @@
+package chatclient
+
+import (
+	"fmt"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// applyReasoningConstraints validates req in place for the o1/o3 reasoning
+// models, which (per the current beta) reject Temperature, TopP,
+// PresencePenalty, FrequencyPenalty, LogitBias, LogProbs, TopLogProbs, N != 1
+// and streaming. Under StrictFieldValidation an unsupported field configured
+// on ChatConfig returns an error; under LenientFieldValidation it is silently
+// dropped from the outgoing request instead.
+func (c *Client) applyReasoningConstraints(req *provider.ChatRequest) error {
+
+	if !req.ReasoningModel {
+		return nil
+	}
+
+	cfg := c.config.ChatConfig
+	unsupported := map[string]bool{
+		"temperature":       cfg.Temperature != 0,
+		"top_p":             cfg.TopP != 0,
+		"presence_penalty":  cfg.PresencePenalty != 0,
+		"frequency_penalty": cfg.FrequencyPenalty != 0,
+		"logit_bias":        len(cfg.LogitBias) > 0,
+		"logprobs":          cfg.LogProbs,
+		"top_logprobs":      cfg.TopLogProbs != 0,
+		"n":                 cfg.N > 1,
+		"stream":            req.Stream,
+	}
+
+	for name, set := range unsupported {
+		if !set {
+			continue
+		}
+		if c.config.FieldValidation == StrictFieldValidation {
+			return fmt.Errorf("field %q is not supported by reasoning model %q", name, req.Model)
+		}
+	}
+
+	// Regardless of mode, never forward the fields the neutral request can
+	// carry further downstream: a reasoning model rejects them outright.
+	// Under StrictFieldValidation this is unreachable for a set field (the
+	// loop above already returned), so it only ever fires in Lenient mode.
+	req.Temperature = 0
+	req.TopP = 0
+	req.PresencePenalty = 0
+	req.FrequencyPenalty = 0
+	req.LogitBias = nil
+	req.N = 0
+	req.Stream = false
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package chatclient
+
+import (
+	"testing"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+func TestApplyReasoningConstraints_NonReasoningModel(t *testing.T) {
+	c := &Client{config: &ClientConfig{FieldValidation: StrictFieldValidation}}
+	req := &provider.ChatRequest{Model: "gpt-4o", ReasoningModel: false, Temperature: 1}
+
+	if err := c.applyReasoningConstraints(req); err != nil {
+		t.Fatalf("unexpected error for a non-reasoning model: %v", err)
+	}
+	if req.Temperature != 1 {
+		t.Errorf("Temperature should be untouched for a non-reasoning model, got %v", req.Temperature)
+	}
+}
+
+func TestApplyReasoningConstraints_Strict(t *testing.T) {
+	c := &Client{
+		config: &ClientConfig{
+			FieldValidation: StrictFieldValidation,
+			ChatConfig:      ChatConfig{Temperature: 0.7},
+		},
+	}
+	req := &provider.ChatRequest{Model: "o1-preview", ReasoningModel: true}
+
+	if err := c.applyReasoningConstraints(req); err == nil {
+		t.Fatal("expected an error for an unsupported field under StrictFieldValidation, got nil")
+	}
+}
+
+func TestApplyReasoningConstraints_Lenient(t *testing.T) {
+	c := &Client{
+		config: &ClientConfig{
+			FieldValidation: LenientFieldValidation,
+			ChatConfig: ChatConfig{
+				Temperature:      0.7,
+				TopP:             0.5,
+				PresencePenalty:  0.1,
+				FrequencyPenalty: 0.1,
+				LogitBias:        map[string]int{"50256": -100},
+				N:                2,
+			},
+		},
+	}
+	req := &provider.ChatRequest{
+		Model:            "o1-preview",
+		ReasoningModel:   true,
+		Temperature:      0.7,
+		TopP:             0.5,
+		PresencePenalty:  0.1,
+		FrequencyPenalty: 0.1,
+		LogitBias:        map[string]int{"50256": -100},
+		N:                2,
+		Stream:           true,
+	}
+
+	if err := c.applyReasoningConstraints(req); err != nil {
+		t.Fatalf("unexpected error under LenientFieldValidation: %v", err)
+	}
+
+	if req.Temperature != 0 || req.TopP != 0 || req.PresencePenalty != 0 || req.FrequencyPenalty != 0 {
+		t.Errorf("expected unsupported numeric fields to be stripped, got %+v", req)
+	}
+	if req.LogitBias != nil {
+		t.Errorf("expected LogitBias to be stripped, got %v", req.LogitBias)
+	}
+	if req.N != 0 {
+		t.Errorf("expected N to be stripped, got %d", req.N)
+	}
+	if req.Stream {
+		t.Error("expected Stream to be stripped for a reasoning model")
+	}
+}
+
+func TestSetInstruction_ReasoningModelUsesUserRole(t *testing.T) {
+	conv := &Conversation{
+		client: &Client{config: &ClientConfig{ChatConfig: ChatConfig{Model: "o1-preview"}}},
+	}
+
+	conv.setInstruction("be concise")
+
+	if len(conv.history) != 1 {
+		t.Fatalf("expected exactly one message in history, got %d", len(conv.history))
+	}
+	if conv.history[0].Role != ai.ChatMessageRoleUser {
+		t.Errorf("expected instruction to be kept as role %q for a reasoning model, got %q", ai.ChatMessageRoleUser, conv.history[0].Role)
+	}
+}
+
+func TestSetInstruction_NonReasoningModelUsesSystemRole(t *testing.T) {
+	conv := &Conversation{
+		client: &Client{config: &ClientConfig{ChatConfig: ChatConfig{Model: "gpt-4o"}}},
+	}
+
+	conv.setInstruction("be concise")
+
+	if len(conv.history) != 1 {
+		t.Fatalf("expected exactly one message in history, got %d", len(conv.history))
+	}
+	if conv.history[0].Role != ai.ChatMessageRoleSystem {
+		t.Errorf("expected instruction to use role %q for a non-reasoning model, got %q", ai.ChatMessageRoleSystem, conv.history[0].Role)
+	}
+}
+
+func TestSetInstruction_UpdatesExistingLeadingMessage(t *testing.T) {
+	conv := &Conversation{
+		client:  &Client{config: &ClientConfig{ChatConfig: ChatConfig{Model: "gpt-4o"}}},
+		history: History{{Role: ai.ChatMessageRoleSystem, Content: "old"}, {Role: ai.ChatMessageRoleUser, Content: "hi"}},
+	}
+
+	conv.setInstruction("new instruction")
+
+	if len(conv.history) != 2 {
+		t.Fatalf("expected history length to stay at 2, got %d", len(conv.history))
+	}
+	if conv.history[0].Content != "new instruction" {
+		t.Errorf("expected the leading message to be updated, got %q", conv.history[0].Content)
+	}
+}
@@ -0,0 +1,273 @@
+package chatclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor reflects t into a JSON-schema document suitable for OpenAI's
+// structured-outputs `response_format: json_schema` mode. Field names and
+// omission follow the `json` tag; the `jsonschema` tag adds a description
+// and/or enum, e.g. `jsonschema:"description=the user's name,enum=a|b|c"`.
+// Pointer and omitempty fields are treated as optional: they stay listed in
+// "required", as strict mode demands, but their type also admits null; slices
+// become arrays; time.Time becomes an RFC3339 string; json.RawMessage
+// becomes an unconstrained value, since its content isn't known until runtime.
+func schemaFor(t reflect.Type) (map[string]any, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice, reflect.Array:
+		items, err := schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Map:
+		return map[string]any{"type": "object"}, nil
+	default:
+		return nil, fmt.Errorf("chatclient: unsupported type %s in structured output schema", t)
+	}
+}
+
+func structSchema(t reflect.Type) (map[string]any, error) {
+	properties := map[string]any{}
+	required := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		name, omitempty := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		optional := omitempty
+		if fieldType.Kind() == reflect.Ptr {
+			optional = true
+			fieldType = fieldType.Elem()
+		}
+
+		fieldSchema, err := schemaFor(fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		applyJSONSchemaTag(fieldSchema, field.Tag.Get("jsonschema"))
+
+		if optional {
+			makeNullable(fieldSchema)
+		}
+
+		properties[name] = fieldSchema
+		// OpenAI's strict structured-outputs mode requires every property to
+		// be listed in "required"; optionality is expressed by widening the
+		// type to a nullable union above, not by omission.
+		required = append(required, name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+// makeNullable widens schema's "type" into a ["T", "null"] union, the way
+// OpenAI's strict structured-outputs mode expects an optional property to be
+// expressed, since strict mode rejects omitting it from "required" instead.
+func makeNullable(schema map[string]any) {
+	t, ok := schema["type"].(string)
+	if !ok {
+		return
+	}
+	schema["type"] = []any{t, "null"}
+}
+
+// validateAgainstSchema checks value, the result of unmarshaling a response
+// into a generic any via encoding/json, against schema (as produced by
+// schemaFor). It catches the cases a strict provider is expected to already
+// rule out but a non-OpenAI backend may not enforce: missing required
+// properties, enum violations, and type mismatches.
+func validateAgainstSchema(schema map[string]any, value any) error {
+	// An optional field's "type" is a ["T", "null"] union (see
+	// structSchema/makeNullable); accept a null value outright, otherwise
+	// validate against the non-null member.
+	if types, ok := schema["type"].([]any); ok {
+		if value == nil {
+			return nil
+		}
+		for _, t := range types {
+			if name, ok := t.(string); ok && name != "null" {
+				narrowed := map[string]any{"type": name}
+				for k, v := range schema {
+					if k != "type" {
+						narrowed[k] = v
+					}
+				}
+				return validateAgainstSchema(narrowed, value)
+			}
+		}
+		return nil
+	}
+
+	schemaType, _ := schema["type"].(string)
+
+	switch schemaType {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", value)
+		}
+
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("missing required property %q", name)
+			}
+		}
+
+		for name, fieldValue := range obj {
+			fieldSchema, ok := properties[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("unexpected property %q", name)
+				}
+				continue
+			}
+			if err := validateAgainstSchema(fieldSchema, fieldValue); err != nil {
+				return fmt.Errorf("property %q: %w", name, err)
+			}
+		}
+
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("expected an array, got %T", value)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range arr {
+			if err := validateAgainstSchema(items, item); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %T", value)
+		}
+		if enum, ok := schema["enum"].([]any); ok {
+			if !containsAny(enum, s) {
+				return fmt.Errorf("value %q is not one of the allowed enum values", s)
+			}
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected a boolean, got %T", value)
+		}
+
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", value)
+		}
+		if schemaType == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("expected an integer, got %v", n)
+		}
+	}
+
+	// A schema with no "type" (e.g. json.RawMessage's unconstrained schema, or
+	// a map[string]any field) accepts any value as-is.
+	return nil
+}
+
+func containsAny(haystack []any, needle string) bool {
+	for _, v := range haystack {
+		if s, ok := v.(string); ok && s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName derives a struct field's JSON name and omitempty-ness from
+// its `json` tag, the same way encoding/json itself does.
+func jsonFieldName(field reflect.StructField) (name string, omitempty bool) {
+	tag := field.Tag.Get("json")
+	parts := strings.Split(tag, ",")
+
+	name = field.Name
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty
+}
+
+// applyJSONSchemaTag merges a `jsonschema:"description=...,enum=a|b|c"` tag
+// into schema.
+func applyJSONSchemaTag(schema map[string]any, tag string) {
+	if tag == "" {
+		return
+	}
+
+	for _, part := range strings.Split(tag, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "description":
+			schema["description"] = kv[1]
+		case "enum":
+			values := strings.Split(kv[1], "|")
+			enum := make([]any, len(values))
+			for i, v := range values {
+				enum[i] = v
+			}
+			schema["enum"] = enum
+		}
+	}
+}
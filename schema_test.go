@@ -0,0 +1,106 @@
+package chatclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaTestPerson struct {
+	Name string `json:"name" jsonschema:"enum=alice|bob"`
+	Age  int    `json:"age,omitempty"`
+}
+
+func TestValidateAgainstSchema_MissingRequired(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	err = validateTyped(`{"age": 30}`, schema)
+	if err == nil {
+		t.Fatal("expected an error for a missing required field, got nil")
+	}
+}
+
+func TestValidateAgainstSchema_EnumViolation(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	err = validateTyped(`{"name": "carol", "age": 30}`, schema)
+	if err == nil {
+		t.Fatal("expected an error for a value outside the enum, got nil")
+	}
+}
+
+func TestValidateAgainstSchema_Valid(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	if err := validateTyped(`{"name": "alice", "age": 30}`, schema); err != nil {
+		t.Fatalf("expected a valid document to pass validation, got: %v", err)
+	}
+}
+
+func TestValidateAgainstSchema_OptionalFieldNull(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	if err := validateTyped(`{"name": "alice", "age": null}`, schema); err != nil {
+		t.Fatalf("expected an explicit null for an optional field to pass validation, got: %v", err)
+	}
+}
+
+func TestValidateAgainstSchema_OptionalFieldOmitted(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	err = validateTyped(`{"name": "alice"}`, schema)
+	if err == nil {
+		t.Fatal("expected an error for an omitted required-but-nullable field, got nil")
+	}
+}
+
+func TestStructSchema_OptionalFieldIsRequiredAndNullable(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	required, _ := schema["required"].([]string)
+	found := false
+	for _, name := range required {
+		if name == "age" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal(`expected "age" to be listed in "required" even though it's optional`)
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	ageSchema, _ := properties["age"].(map[string]any)
+	types, ok := ageSchema["type"].([]any)
+	if !ok || len(types) != 2 || types[0] != "integer" || types[1] != "null" {
+		t.Fatalf(`expected age's type to be ["integer", "null"], got %#v`, ageSchema["type"])
+	}
+}
+
+func TestValidateAgainstSchema_TypeMismatch(t *testing.T) {
+	schema, err := schemaFor(reflect.TypeOf(schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("schemaFor: %v", err)
+	}
+
+	err = validateTyped(`{"name": "alice", "age": "thirty"}`, schema)
+	if err == nil {
+		t.Fatal("expected an error for a type mismatch, got nil")
+	}
+}
@@ -0,0 +1,225 @@
+package chatclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// StreamEventType identifies the kind of event carried by a StreamEvent.
+type StreamEventType int
+
+const (
+	// EventStart opens the stream, carrying the model the request was issued
+	// against.
+	EventStart StreamEventType = iota
+	// EventContentDelta carries one piece of the assistant's streamed text.
+	EventContentDelta
+	// EventToolCallDelta carries one piece of a tool call's streamed
+	// arguments, for providers that stream them incrementally (see
+	// provider.StreamChunk.ToolCallDelta).
+	EventToolCallDelta
+	// EventFinish is the last event on a successful stream, carrying the
+	// finish reason and, when the provider reports it, token usage.
+	EventFinish
+	// EventError is the last event on a failed stream; no further events follow.
+	EventError
+)
+
+// ToolCallDelta is one incremental piece of a tool call's arguments as they
+// stream in, mirroring provider.ToolCallDelta.
+type ToolCallDelta struct {
+	ID             string
+	Name           string
+	ArgumentsDelta string
+}
+
+// StreamEvent is one event on the channel returned by PromptStreamContext.
+// Which fields are set depends on Type: see each StreamEventType's doc comment.
+type StreamEvent struct {
+	Type StreamEventType
+
+	Model string
+
+	Content string
+
+	ToolCallDelta ToolCallDelta
+
+	FinishReason string
+	Usage        *provider.Usage
+
+	Err error
+}
+
+// StreamHandle is returned by PromptStreamContext. Events is closed once the
+// stream finishes, is cancelled, or fails; Cancel stops the in-flight request
+// explicitly, instead of a consumer having to stop reading and let chunks be
+// silently dropped.
+type StreamHandle struct {
+	events chan StreamEvent
+	cancel context.CancelFunc
+}
+
+// Events returns the channel of StreamEvents.
+func (h *StreamHandle) Events() <-chan StreamEvent {
+	return h.events
+}
+
+// Cancel stops the in-flight request. Safe to call more than once, and safe
+// to call after the stream has already finished.
+func (h *StreamHandle) Cancel() {
+	h.cancel()
+}
+
+// PromptStreamContext behaves like PromptStream, except it returns a
+// StreamHandle carrying a tagged StreamEvent union (EventStart,
+// EventContentDelta, EventToolCallDelta, EventFinish, EventError) instead of
+// a flat Stream, and exposes an explicit Cancel method on the handle so a
+// caller can stop a long generation cleanly.
+func (conv *Conversation) PromptStreamContext(ctx context.Context, question string) *StreamHandle {
+	ctx, cancel := context.WithCancel(ctx)
+
+	handle := &StreamHandle{
+		events: make(chan StreamEvent),
+		cancel: cancel,
+	}
+
+	go func() {
+		defer close(handle.events)
+		defer cancel()
+
+		conv.mu.Lock()
+		defer conv.mu.Unlock()
+
+		c := conv.client
+
+		if c.shouldModerateInput() {
+			err := c.moderateInput(ctx, question)
+			if err == ErrModeration {
+				sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: ErrModerationUserInput})
+				return
+			}
+			if err != nil {
+				sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: err})
+				return
+			}
+		}
+
+		req, err := conv.newChatCompletionRequest(ctx, question, true)
+		if err != nil {
+			sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: err})
+			return
+		}
+
+		if !sendEvent(ctx, handle.events, StreamEvent{Type: EventStart, Model: req.Model}) {
+			return
+		}
+
+		reqCtx, reqCancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+		defer reqCancel()
+
+		if !req.Stream {
+			// LenientFieldValidation stripped streaming for a reasoning model
+			// that rejects it; fall back to a single non-streaming completion
+			// delivered as one EventContentDelta instead.
+			resp, err := c.provider.CreateChatCompletion(reqCtx, req)
+			if err != nil {
+				sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: err})
+				return
+			}
+			if !sendEvent(ctx, handle.events, StreamEvent{Type: EventContentDelta, Content: resp.Content}) {
+				return
+			}
+			conv.postStreamResponse(resp.Content, &resp.Usage)
+			sendEvent(ctx, handle.events, StreamEvent{Type: EventFinish, FinishReason: resp.FinishReason, Usage: &resp.Usage})
+			return
+		}
+
+		stream, err := c.provider.CreateChatCompletionStream(reqCtx, req)
+		if err != nil {
+			sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: err})
+			return
+		}
+
+		sb := strings.Builder{}
+		var usage *provider.Usage
+		var finishReason string
+		for chunk := range stream {
+			if chunk.Err != nil {
+				// Some providers (e.g. openai) end a completed stream with io.EOF.
+				if errors.Is(chunk.Err, io.EOF) {
+					break
+				}
+				sendEvent(ctx, handle.events, StreamEvent{Type: EventError, Err: chunk.Err})
+				return
+			}
+
+			if chunk.Content != "" {
+				if !sendEvent(ctx, handle.events, StreamEvent{Type: EventContentDelta, Content: chunk.Content}) {
+					return
+				}
+				sb.WriteString(chunk.Content)
+			}
+
+			if chunk.ToolCallDelta != nil {
+				delta := StreamEvent{
+					Type: EventToolCallDelta,
+					ToolCallDelta: ToolCallDelta{
+						ID:             chunk.ToolCallDelta.ID,
+						Name:           chunk.ToolCallDelta.Name,
+						ArgumentsDelta: chunk.ToolCallDelta.ArgumentsDelta,
+					},
+				}
+				if !sendEvent(ctx, handle.events, delta) {
+					return
+				}
+			}
+
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if chunk.FinishReason != "" {
+				finishReason = chunk.FinishReason
+			}
+		}
+
+		conv.postStreamResponse(sb.String(), usage)
+		sendEvent(ctx, handle.events, StreamEvent{Type: EventFinish, FinishReason: finishReason, Usage: usage})
+	}()
+
+	return handle
+}
+
+// sendEvent delivers event on events, honoring ctx's cancellation instead of
+// blocking forever against a consumer that stopped reading. It reports
+// whether the send went through.
+func sendEvent(ctx context.Context, events chan<- StreamEvent, event StreamEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// CollectString drains events, concatenating every EventContentDelta's
+// Content, for callers who just want the final text. If an EventError is
+// received, its Err is returned alongside whatever text had been collected
+// so far.
+func CollectString(events <-chan StreamEvent) (string, error) {
+	sb := strings.Builder{}
+
+	for event := range events {
+		switch event.Type {
+		case EventContentDelta:
+			sb.WriteString(event.Content)
+		case EventError:
+			return sb.String(), event.Err
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,352 @@
+package chatclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+const defaultMaxToolIterations = 8
+
+// ToolFunc is a registered tool's implementation. args is the raw JSON
+// arguments the model produced for the call.
+type ToolFunc func(ctx context.Context, args json.RawMessage) (string, error)
+
+type registeredTool struct {
+	def          provider.Tool
+	fn           ToolFunc
+	fatalOnError bool
+}
+
+// ToolRegistry holds the tools a PromptWithTools/PromptStreamWithTools call
+// may dispatch to. The zero value is not usable; create one with NewToolRegistry.
+type ToolRegistry struct {
+	tools map[string]registeredTool
+}
+
+// NewToolRegistry creates an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: map[string]registeredTool{}}
+}
+
+// Register adds a tool under name, described to the model by description and
+// the JSON-schema parameters, invoking fn when the model calls it. An error
+// returned by fn is surfaced back to the model as the tool's result.
+func (r *ToolRegistry) Register(name, description string, parameters any, fn ToolFunc) {
+	r.tools[name] = registeredTool{
+		def: provider.Tool{Name: name, Description: description, Parameters: parameters},
+		fn:  fn,
+	}
+}
+
+// RegisterFatal behaves like Register, except an error returned by fn aborts
+// the whole PromptWithTools/PromptStreamWithTools call instead of being
+// reported back to the model as the tool's result.
+func (r *ToolRegistry) RegisterFatal(name, description string, parameters any, fn ToolFunc) {
+	r.tools[name] = registeredTool{
+		def:          provider.Tool{Name: name, Description: description, Parameters: parameters},
+		fn:           fn,
+		fatalOnError: true,
+	}
+}
+
+func (r *ToolRegistry) definitions() []provider.Tool {
+	defs := make([]provider.Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		defs = append(defs, t.def)
+	}
+	return defs
+}
+
+// PromptWithTools sends a prompt to the underlying provider, giving it access
+// to the tools registered on registry. When the model responds with tool
+// calls, each is dispatched concurrently through the registry, its result (or
+// error) is appended to history as a role:"tool" message, and the model is
+// re-invoked. This repeats until the model returns a plain text response or
+// ClientConfig.MaxToolIterations is reached, whichever happens first.
+func (conv *Conversation) PromptWithTools(ctx context.Context, prompt string, registry *ToolRegistry) (string, error) {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	c := conv.client
+
+	if c.shouldModerateInput() {
+		err := c.moderateInput(ctx, prompt)
+		if err == ErrModeration {
+			return "", ErrModerationUserInput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleUser, Content: prompt}
+	conv.history = append(conv.history, msg)
+	conv.persist(msg)
+
+	response, err := conv.runToolLoop(ctx, registry, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if c.shouldModerateOutput() {
+		err := c.moderateInput(ctx, response)
+		if err == ErrModeration {
+			return "", ErrModerationModelOutput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return response, nil
+}
+
+// PromptStreamWithTools behaves like PromptWithTools, except the final plain
+// text answer is delivered over the returned channel instead of as a return
+// value. Tool-call rounds are inherently non-streaming (the model's decision
+// to call a tool has to be read in full before it can be dispatched), so no
+// round is streamed: the final answer arrives as a single chunk, and
+// intermediate tool-calling turns never reach the channel.
+func (conv *Conversation) PromptStreamWithTools(ctx context.Context, prompt string, registry *ToolRegistry) <-chan Stream {
+
+	ch := make(chan Stream)
+
+	go func() {
+		defer close(ch)
+
+		conv.mu.Lock()
+		defer conv.mu.Unlock()
+
+		c := conv.client
+
+		if c.shouldModerateInput() {
+			err := c.moderateInput(ctx, prompt)
+			if err == ErrModeration {
+				ch <- Stream{Err: ErrModerationUserInput}
+				return
+			}
+			if err != nil {
+				ch <- Stream{Err: err}
+				return
+			}
+		}
+
+		userMsg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleUser, Content: prompt}
+		conv.history = append(conv.history, userMsg)
+		conv.persist(userMsg)
+
+		response, err := conv.runToolLoop(ctx, registry, nil)
+		if err != nil {
+			ch <- Stream{Err: err}
+			return
+		}
+
+		if c.shouldModerateOutput() {
+			err := c.moderateInput(ctx, response)
+			if err == ErrModeration {
+				ch <- Stream{Err: ErrModerationModelOutput}
+				return
+			}
+			if err != nil {
+				ch <- Stream{Err: err}
+				return
+			}
+		}
+
+		ch <- Stream{Chunk: response}
+	}()
+
+	return ch
+}
+
+// runToolLoop drives the tool-calling loop shared by PromptWithTools,
+// PromptStreamWithTools and Agent.PromptStream: it re-invokes the model,
+// dispatching any tool calls it requests, until the model returns a plain
+// text response or ClientConfig.MaxToolIterations is reached. If onToolCall
+// is non-nil, it is called once per tool call, right before the call is
+// dispatched, so callers like Agent.PromptStream can report progress.
+// Callers must hold conv.mu.
+func (conv *Conversation) runToolLoop(ctx context.Context, registry *ToolRegistry, onToolCall func(provider.ToolCall)) (string, error) {
+
+	maxIterations := conv.client.config.MaxToolIterations
+	if maxIterations == 0 {
+		maxIterations = defaultMaxToolIterations
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		resp, err := conv.completeWithTools(ctx, registry)
+		if err != nil {
+			return "", err
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleAssistant, Content: resp.Content}
+			conv.history = append(conv.history, msg)
+			conv.persist(msg)
+			conv.billConsumedTokens(resp.Usage.TotalTokens)
+			return resp.Content, nil
+		}
+
+		msg := ai.ChatCompletionMessage{
+			Role:      ai.ChatMessageRoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: toAiToolCalls(resp.ToolCalls),
+		}
+		conv.history = append(conv.history, msg)
+		conv.persist(msg)
+		conv.billConsumedTokens(resp.Usage.TotalTokens)
+
+		if onToolCall != nil {
+			for _, call := range resp.ToolCalls {
+				onToolCall(call)
+			}
+		}
+
+		if err := conv.dispatchToolCalls(ctx, registry, resp.ToolCalls); err != nil {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("tool calling loop exceeded MaxToolIterations (%d)", maxIterations)
+}
+
+// dispatchToolCalls invokes each requested tool concurrently and appends its
+// result (or error) to history as a role:"tool" message, in the same order
+// the calls were requested.
+func (conv *Conversation) dispatchToolCalls(ctx context.Context, registry *ToolRegistry, calls []provider.ToolCall) error {
+
+	results := make([]ai.ChatCompletionMessage, len(calls))
+	errs := make([]error, len(calls))
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call provider.ToolCall) {
+			defer wg.Done()
+			results[i], errs[i] = conv.invokeTool(ctx, registry, call)
+		}(i, call)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	conv.history = append(conv.history, results...)
+	conv.persist(results...)
+	return nil
+}
+
+func (conv *Conversation) invokeTool(ctx context.Context, registry *ToolRegistry, call provider.ToolCall) (ai.ChatCompletionMessage, error) {
+
+	tool, ok := registry.tools[call.Name]
+	if !ok {
+		return toolResultMessage(call.ID, fmt.Sprintf("error: unknown tool %q", call.Name)), nil
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, conv.client.config.ApiTimeout)
+	defer cancel()
+
+	retryHandler := newRetryHandler(time.Second*5, 5)
+	var result string
+	var err error
+
+	retryHandler.Do(func() error {
+		result, err = tool.fn(callCtx, json.RawMessage(call.Arguments))
+		return err
+	})
+
+	if err != nil {
+		if tool.fatalOnError {
+			return ai.ChatCompletionMessage{}, fmt.Errorf("tool %q failed: %w", call.Name, err)
+		}
+		return toolResultMessage(call.ID, fmt.Sprintf("error: %v", err)), nil
+	}
+
+	return toolResultMessage(call.ID, result), nil
+}
+
+func toolResultMessage(toolCallID, content string) ai.ChatCompletionMessage {
+	return ai.ChatCompletionMessage{
+		Role:       ai.ChatMessageRoleTool,
+		Content:    content,
+		ToolCallID: toolCallID,
+	}
+}
+
+// completeWithTools is the tool-aware counterpart to newChatCompletionRequest:
+// it builds the request the same way but also attaches registry's tool
+// definitions, then issues the completion through the retry handler.
+func (conv *Conversation) completeWithTools(ctx context.Context, registry *ToolRegistry) (provider.ChatResponse, error) {
+
+	c := conv.client
+
+	conv.trimHistory(ctx)
+
+	model := c.config.ChatConfig.Model
+	request := provider.ChatRequest{
+		Model:            model,
+		Messages:         toProviderMessages(conv.history),
+		Temperature:      c.config.ChatConfig.Temperature,
+		MaxTokens:        c.config.ChatConfig.MaxTokens,
+		TopP:             c.config.ChatConfig.TopP,
+		Stop:             c.config.ChatConfig.Stop,
+		PresencePenalty:  c.config.ChatConfig.PresencePenalty,
+		FrequencyPenalty: c.config.ChatConfig.FrequencyPenalty,
+		LogitBias:        c.config.ChatConfig.LogitBias,
+		N:                c.config.ChatConfig.N,
+		Seed:             c.config.ChatConfig.Seed,
+		User:             c.config.ChatConfig.User,
+		Tools:            registry.definitions(),
+		ToolChoice:       toProviderToolChoice(c.config.ChatConfig.ToolChoice),
+		ReasoningModel:   isReasoningModel(model),
+		ReasoningEffort:  c.config.ChatConfig.ReasoningEffort,
+	}
+
+	if err := c.applyReasoningConstraints(&request); err != nil {
+		return provider.ChatResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+	defer cancel()
+
+	retryHandler := newRetryHandler(time.Second*5, 5)
+	var resp provider.ChatResponse
+	var err error
+
+	retryHandler.Do(func() error {
+		resp, err = c.provider.CreateChatCompletion(ctx, request)
+		return err
+	})
+
+	return resp, err
+}
+
+func toAiToolCalls(calls []provider.ToolCall) []ai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	out := make([]ai.ToolCall, len(calls))
+	for i, c := range calls {
+		out[i] = ai.ToolCall{
+			ID:   c.ID,
+			Type: ai.ToolTypeFunction,
+			Function: ai.FunctionCall{
+				Name:      c.Name,
+				Arguments: c.Arguments,
+			},
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,131 @@
+package chatclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/provider"
+)
+
+// defaultRepairBudget caps how many times PromptTyped re-asks the model after
+// a response fails to parse as JSON or to validate against T's schema, when
+// the caller passes a negative budget.
+const defaultRepairBudget = 2
+
+// PromptTyped sends prompt to conv constrained by T's JSON schema (OpenAI's
+// `response_format: json_schema` mode with `strict: true`), and unmarshals
+// the response into a T. T is reflected into the schema via schemaFor,
+// honoring `json` tags for field names and `jsonschema` tags for
+// descriptions/enums; see schemaFor for the supported shapes.
+//
+// A strict schema response is guaranteed by OpenAI to match the requested
+// shape, but providers without native structured-output support (see
+// provider.ChatRequest.ResponseSchema) may return anything, so the response
+// is also validated against schema via validateAgainstSchema before being
+// unmarshaled into T. If parsing or validation fails, the error is fed back
+// to the model as a user message and the prompt is retried, up to
+// repairBudget additional attempts (a negative value uses defaultRepairBudget).
+func PromptTyped[T any](ctx context.Context, conv *Conversation, prompt string, repairBudget int) (T, error) {
+	var zero T
+
+	if repairBudget < 0 {
+		repairBudget = defaultRepairBudget
+	}
+
+	t := reflect.TypeOf(zero)
+	schema, err := schemaFor(t)
+	if err != nil {
+		return zero, err
+	}
+
+	name := t.Name()
+	if name == "" {
+		name = "response"
+	}
+
+	question := prompt
+	for attempt := 0; ; attempt++ {
+		raw, err := conv.promptTyped(ctx, question, name, schema)
+		if err != nil {
+			return zero, err
+		}
+
+		err = validateTyped(raw, schema)
+		if err == nil {
+			var v T
+			if err := json.Unmarshal([]byte(raw), &v); err != nil {
+				return zero, fmt.Errorf("chatclient: response passed schema validation but did not unmarshal into %s: %w", name, err)
+			}
+			return v, nil
+		}
+
+		if attempt >= repairBudget {
+			return zero, fmt.Errorf("chatclient: response did not match schema %q after %d attempts: %w", name, attempt+1, err)
+		}
+		question = fmt.Sprintf("Your previous response could not be parsed: %v. Respond again with valid JSON matching the required schema.", err)
+	}
+}
+
+// validateTyped decodes raw as generic JSON and checks it against schema,
+// catching the cases a non-OpenAI backend (which doesn't enforce
+// provider.ChatRequest.ResponseSchema) may let through: missing required
+// fields, enum violations and type mismatches that json.Unmarshal alone
+// wouldn't reject.
+func validateTyped(raw string, schema map[string]any) error {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return err
+	}
+	return validateAgainstSchema(schema, v)
+}
+
+// promptTyped is PromptTyped's non-generic Conversation half: it builds and
+// issues one request constrained to schema, mirroring prompt's moderation,
+// retry and history bookkeeping.
+func (conv *Conversation) promptTyped(ctx context.Context, question, schemaName string, schema map[string]any) (string, error) {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	c := conv.client
+
+	if c.shouldModerateInput() {
+		err := c.moderateInput(ctx, question)
+		if err == ErrModeration {
+			return "", ErrModerationUserInput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	req, err := conv.newChatCompletionRequest(ctx, question, false)
+	if err != nil {
+		return "", err
+	}
+	req.ResponseSchema = &provider.ResponseSchema{Name: schemaName, Schema: schema, Strict: true}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.ApiTimeout)
+	defer cancel()
+
+	retryHandler := newRetryHandler(time.Second*5, 5)
+	var resp provider.ChatResponse
+	retryHandler.Do(func() error {
+		resp, err = c.provider.CreateChatCompletion(ctx, req)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	msg := ai.ChatCompletionMessage{Role: ai.ChatMessageRoleAssistant, Content: resp.Content}
+	conv.history = append(conv.history, msg)
+	conv.persist(msg)
+	conv.billConsumedTokens(resp.Usage.TotalTokens)
+
+	return resp.Content, nil
+}
@@ -5,6 +5,12 @@ import (
 
 	"github.com/sashabaranov/go-openai"
 	ai "github.com/sashabaranov/go-openai"
+
+	"github.com/m-ariany/gpt-chat-client/historystore"
+	"github.com/m-ariany/gpt-chat-client/providers/anthropic"
+	"github.com/m-ariany/gpt-chat-client/providers/azureopenai"
+	"github.com/m-ariany/gpt-chat-client/providers/gemini"
+	"github.com/m-ariany/gpt-chat-client/providers/ollama"
 )
 
 type (
@@ -17,14 +23,46 @@ type (
 		Err   error
 	}
 
+	// FieldValidationMode controls how newChatCompletionRequest reacts when the
+	// target model (e.g. an o1/o3 reasoning model) does not support a field set
+	// on ChatConfig.
+	FieldValidationMode int
+
+	// MemoryStrategy controls what trimHistoryToMatchTokenLimit does with
+	// messages it evicts to stay within a token budget.
+	MemoryStrategy int
+
 	ClientConfig struct {
-		// ApiUrl is the URL of the OpenAI API.
+		// Provider selects the backend LLM provider. Supported values are
+		// ProviderOpenAI (default), ProviderAnthropic, ProviderAzureOpenAI,
+		// ProviderGemini and ProviderOllama.
+		Provider string
+
+		// ApiUrl is the URL of the OpenAI API. Only used when Provider is ProviderOpenAI.
 		ApiUrl string
 
-		// ApiKey is the authentication key required to access the OpenAI API.
+		// ApiKey is the authentication key required to access the OpenAI API. Only used when Provider is ProviderOpenAI.
 		ApiKey string
 
-		// ApiTimeout specifies the maximum duration to wait for a response from the OpenAI API.
+		// AnthropicConfig holds provider-specific settings when Provider is ProviderAnthropic.
+		AnthropicConfig *anthropic.Config
+
+		// AzureConfig holds provider-specific settings when Provider is ProviderAzureOpenAI.
+		AzureConfig *azureopenai.Config
+
+		// GeminiConfig holds provider-specific settings when Provider is ProviderGemini.
+		GeminiConfig *gemini.Config
+
+		// OllamaConfig holds provider-specific settings when Provider is ProviderOllama.
+		OllamaConfig *ollama.Config
+
+		// HistoryStore persists every Conversation's history outside of process
+		// memory, so a long-running service can restart and resume where it left
+		// off. Conversations created while this is nil keep their history in
+		// memory only, as before. See historystore.Store and Client.NewConversation.
+		HistoryStore historystore.Store
+
+		// ApiTimeout specifies the maximum duration to wait for a response from the underlying provider.
 		ApiTimeout time.Duration
 
 		// MemoryTokenSize specifies the maximum number of tokens to remember in the conversation history.
@@ -33,12 +71,70 @@ type (
 		// MemoryMessageSize specifies the maximum number of messages to remember in the conversation history.
 		MemoryMessageSize *int
 
+		// MemoryStrategy controls what happens to messages evicted by
+		// MemoryTokenSize/the model's context length. Defaults to DropOldest.
+		MemoryStrategy MemoryStrategy
+
+		// SummaryModel overrides the model used to produce rolling summaries
+		// for MemoryStrategy Summarize/Hybrid. Defaults to ChatConfig.Model.
+		SummaryModel string
+
+		// SummaryEveryNEvictions sets how many evicted messages Hybrid
+		// accumulates before folding them into the rolling summary. Defaults
+		// to defaultSummaryEveryNEvictions.
+		SummaryEveryNEvictions int
+
+		// SummaryTokenSize caps the rolling summary's own token count for
+		// MemoryStrategy Summarize/Hybrid; once exceeded, it is condensed
+		// further. Unlimited when nil.
+		SummaryTokenSize *int
+
 		// ModeratePromptMessage indicates whether the client should check the prompt message agains the moderation endpoint.
 		ModeratePromptMessage *bool
 
 		// ModerateResponse indicates whether the client should check the response against the moderation endpoint.
 		ModerateResponse *bool
 
+		// MaxToolIterations caps how many completions PromptWithTools/PromptStreamWithTools
+		// issue for one prompt, the first one included; if the model still asks
+		// for tools on the last of them, the call returns an error.
+		// Defaults to defaultMaxToolIterations.
+		MaxToolIterations int
+
+		// FieldValidation controls how unsupported ChatConfig fields (e.g.
+		// Temperature or streaming on an o1/o3 reasoning model) are handled.
+		// Defaults to StrictFieldValidation.
+		FieldValidation FieldValidationMode
+
 		ChatConfig ChatConfig
 	}
 )
+
+const (
+	// StrictFieldValidation returns an error from newChatCompletionRequest when
+	// ChatConfig sets a field the target model does not support.
+	StrictFieldValidation FieldValidationMode = iota
+	// LenientFieldValidation silently strips fields the target model does not
+	// support instead of erroring.
+	LenientFieldValidation
+)
+
+const (
+	ProviderOpenAI      = "openai"
+	ProviderAnthropic   = "anthropic"
+	ProviderAzureOpenAI = "azureopenai"
+	ProviderGemini      = "gemini"
+	ProviderOllama      = "ollama"
+)
+
+const (
+	// DropOldest discards evicted messages outright. This is the default.
+	DropOldest MemoryStrategy = iota
+	// Summarize folds every evicted message into a rolling summary kept at
+	// the front of history, right after the instruction.
+	Summarize
+	// Hybrid discards evicted messages like DropOldest, except every
+	// SummaryEveryNEvictions of them are instead folded into the rolling
+	// summary, amortizing the cost of summarization against lost context.
+	Hybrid
+)